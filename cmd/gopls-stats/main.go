@@ -18,76 +18,98 @@ import (
 	"github.com/stamblerre/work-stats/golang"
 	"github.com/wcharczuk/go-chart/v2"
 	"golang.org/x/build/maintner/godata"
-	"golang.org/x/oauth2"
 )
 
 var (
-	since          = flag.String("since", "", "date from which to collect data")
+	since          = flag.String("since", "", "date or period from which to collect data (see generic.ParsePeriod)")
+	until          = flag.String("until", "", "date or period until which to collect data, inclusive")
+	tz             = flag.String("tz", "Local", "time zone in which to interpret dates and periods, such as America/New_York")
 	repos          = flag.String("repos", "", "repositories to process, comma separated")
 	checkTransfers = flag.Bool("check-transfers", false, "true if we care about whether or not issues were transferred")
+	rulesFile      = flag.String("rules", "", "JSON file of rules that classify issues into the series of each chart (see generic.LoadRules), instead of charting feature requests and other issues")
 )
 
+// defaultRules chart feature requests separately from other issues.
+var defaultRules = generic.Rules{
+	{Category: "Feature requests", Labels: []string{"FeatureRequest"}},
+}
+
+// goplsRules select the gopls issues in the main Go repository.
+var goplsRules = generic.Rules{
+	{Category: "gopls", Labels: []string{"gopls"}},
+}
+
 func main() {
 	flag.Parse()
 
-	// Parse out the start date, if provided.
-	var (
-		start time.Time
-		err   error
-	)
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Fatal(err)
+	}
+	now := time.Now().In(loc)
+
+	// Parse out the start and end dates, if provided.
+	r := generic.Range{Start: time.Date(1900, time.January, 1, 0, 0, 0, 0, loc), End: now}
 	if *since != "" {
-		start, err = time.Parse("2006-01-02", *since)
+		p, err := generic.ParsePeriod(now, *since)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r.Start = p.Start
+	}
+	if *until != "" {
+		p, err := generic.ParsePeriod(now, *until)
 		if err != nil {
 			log.Fatal(err)
 		}
-	} else {
-		start = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+		r.End = p.End
 	}
 
-	ctx := context.Background()
+	rules := defaultRules
+	if *rulesFile != "" {
+		if rules, err = generic.LoadRules(*rulesFile); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	end := time.Now()
+	ctx := context.Background()
 
 	// Get the corpus data (very slow on first try, uses cache after).
 	corpus, err := godata.Get(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
-	vscodeIssues, err := golang.Issues(corpus.GitHub(), "vscode-go", "", start, end)
+	vscodeIssues, err := golang.Issues(corpus, nil, "vscode-go", "", nil, r)
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := issuesToGraph("vscode-go.png", vscodeIssues, start, end); err != nil {
+	if err := issuesToGraph("vscode-go.png", vscodeIssues, rules, r); err != nil {
 		log.Fatal(err)
 	}
-	toolsIssues, err := golang.Issues(corpus.GitHub(), "go", "", start, end)
+	toolsIssues, err := golang.Issues(corpus, nil, "go", "", nil, r)
 	if err != nil {
 		log.Fatal(err)
 	}
 	var goplsIssues []*generic.Issue
 	for _, issue := range toolsIssues {
-		var hasGoplsLabel bool
-		for _, label := range issue.Labels {
-			if label == "gopls" {
-				hasGoplsLabel = true
-				break
-			}
-		}
-		if hasGoplsLabel {
+		if goplsRules.IssueCategory(issue) != "" {
 			goplsIssues = append(goplsIssues, issue)
 		}
 	}
-	if err := issuesToGraph("gopls.png", goplsIssues, start, end); err != nil {
+	if err := issuesToGraph("gopls.png", goplsIssues, rules, r); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func issuesToGraph(filename string, incomingIssues []*generic.Issue, start, end time.Time) error {
-	var dates []time.Time
-	inclusiveEnd := end.Add(time.Hour * 24)
-	for t := start; t.Before(inclusiveEnd); {
-		dates = append(dates, time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
-		t = t.Add(time.Hour * 24)
+// issuesToGraph charts the number of open issues on each day of r, with a
+// series for each category of the rules, and one for the issues that no rule
+// matches.
+func issuesToGraph(filename string, incomingIssues []*generic.Issue, rules generic.Rules, r generic.Range) error {
+	dates := r.Days()
+	loc := r.Start.Location()
+	day := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
 	}
 	var issues []*generic.Issue
 	for _, issue := range incomingIssues {
@@ -114,7 +136,7 @@ func issuesToGraph(filename string, incomingIssues []*generic.Issue, start, end
 		if !issue.Closed() {
 			continue
 		}
-		sum += end.Sub(start).Hours()
+		sum += r.End.Sub(r.Start).Hours()
 		total++
 	}
 	timeToClose := sum / float64(total)
@@ -124,28 +146,51 @@ func issuesToGraph(filename string, incomingIssues []*generic.Issue, start, end
 	}
 	days := int(math.Floor(parsed.Hours() / 24.0))
 	log.Printf("Average time to close an issue is %v days.", days)
-	count := map[time.Time]float64{}
-	fr := map[time.Time]float64{}
+	// Count the open issues in each category by day. Issues that no rule
+	// matches are in the "" category.
+	counts := map[string]map[time.Time]float64{"": {}}
+	for _, rule := range rules {
+		counts[rule.Category] = map[time.Time]float64{}
+	}
 	for _, date := range dates {
 		for _, issue := range issues {
-			opened := time.Date(issue.DateOpened.Year(), issue.DateOpened.Month(), issue.DateOpened.Day(), 0, 0, 0, 0, time.UTC)
-			closed := time.Date(issue.DateClosed.Year(), issue.DateClosed.Month(), issue.DateClosed.Day(), 0, 0, 0, 0, time.UTC)
-			if !inScope(date, opened, closed) {
-				continue
+			// An issue is open from the day it was opened through the day
+			// it was closed, if it has been.
+			open := generic.Range{Start: day(issue.DateOpened), End: r.End}
+			if issue.Closed() {
+				open.End = day(issue.DateClosed).AddDate(0, 0, 1)
 			}
-			if isFeatureRequest(issue) {
-				fr[date]++
-			} else {
-				count[date]++
+			if !open.Contains(date) {
+				continue
 			}
+			counts[rules.IssueCategory(issue)][date]++
 		}
 	}
-	countSlice := make([]float64, len(dates))
-	frSlice := make([]float64, len(dates))
-	for i, date := range dates {
-		countSlice[i] = float64(count[date])
-		frSlice[i] = float64(fr[date])
+	// Chart the categories in the order of the rules, followed by the other
+	// issues.
+	var series []chart.Series
+	addSeries := func(category, name string) {
+		count, ok := counts[category]
+		if !ok {
+			// Several rules may share a category.
+			return
+		}
+		delete(counts, category)
+		values := make([]float64, len(dates))
+		for i, date := range dates {
+			values[i] = count[date]
+		}
+		series = append(series, chart.TimeSeries{
+			XValues: dates,
+			YValues: values,
+			Style:   chart.Shown(),
+			Name:    name,
+		})
 	}
+	for _, rule := range rules {
+		addSeries(rule.Category, rule.Category)
+	}
+	addSeries("", "Other issues")
 	graph := chart.Chart{
 		Title:      filename,
 		TitleStyle: chart.Shown(),
@@ -159,20 +204,7 @@ func issuesToGraph(filename string, incomingIssues []*generic.Issue, start, end
 			NameStyle:      chart.Shown(),
 			Style:          chart.Shown(),
 		},
-		Series: []chart.Series{
-			chart.TimeSeries{
-				XValues: dates,
-				YValues: frSlice,
-				Style:   chart.Shown(),
-				Name:    "Feature requests",
-			},
-			chart.TimeSeries{
-				XValues: dates,
-				YValues: countSlice,
-				Style:   chart.Shown(),
-				Name:    "Other issues",
-			},
-		},
+		Series: series,
 	}
 	graph.Elements = []chart.Renderable{
 		chart.LegendLeft(&graph),
@@ -182,37 +214,16 @@ func issuesToGraph(filename string, incomingIssues []*generic.Issue, start, end
 	return graph.Render(chart.PNG, f)
 }
 
-func inScope(t, start, end time.Time) bool {
-	afterStart := t.Equal(start) || t.After(start)
-	if end.IsZero() {
-		return afterStart
-	}
-	return afterStart && (t.Equal(end) || t.Before(end))
-}
-
-func isFeatureRequest(issue *generic.Issue) bool {
-	for _, label := range issue.Labels {
-		if label == "FeatureRequest" {
-			return true
-		}
-	}
-	return false
-}
-
 var once sync.Once
 var client *gh.Client
 
 func wasTransferred(ctx context.Context, owner, repo string, number int32) (bool, error) {
 	once.Do(func() {
-		token := os.Getenv("GITHUB_TOKEN")
-		if token == "" {
-			panic("GITHUB_TOKEN environment variable is not configured")
+		var err error
+		client, err = github.NewClient(ctx)
+		if err != nil {
+			panic(err)
 		}
-		ts := oauth2.StaticTokenSource(&oauth2.Token{
-			AccessToken: token,
-		})
-		tc := oauth2.NewClient(ctx, ts)
-		client = gh.NewClient(tc)
 	})
 	return github.WasTransferred(ctx, client, owner, repo, number)
 }
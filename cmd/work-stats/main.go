@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -9,36 +10,90 @@ import (
 	"strings"
 	"time"
 
+	gogithub "github.com/google/go-github/v28/github"
 	"github.com/stamblerre/sheets"
 	"github.com/stamblerre/work-stats/generic"
 	"github.com/stamblerre/work-stats/github"
 	"github.com/stamblerre/work-stats/golang"
+	"golang.org/x/build/maintner"
 	"golang.org/x/build/maintner/godata"
 	gsheets "google.golang.org/api/sheets/v4"
 )
 
 var (
-	username = flag.String("username", "", "GitHub username")
-	email    = flag.String("email", "", "Gerrit email or emails, comma-separated")
-	since    = flag.String("since", "", "date from which to collect data")
-	until    = flag.String("until", "", "date until which to collect data")
+	username  = flag.String("username", "", "GitHub username")
+	email     = flag.String("email", "", "Gerrit email or emails, comma-separated")
+	accountID = flag.String("gerrit-account-id", "", "Gerrit account ID or IDs, comma-separated, to use instead of looking them up by -email")
+	since     = flag.String("since", "", "date or period from which to collect data (see generic.ParsePeriod)")
+	until     = flag.String("until", "", "date or period until which to collect data, inclusive")
+	period    = flag.String("period", "", "period for which to collect data, such as 2024-Q3 or last-month, instead of -since and -until")
+	tz        = flag.String("tz", "Local", "time zone in which to interpret dates and periods, such as America/New_York")
+
+	// Flags relating to comparison reports.
+	previousSince = flag.String("previous-since", "", "with compare, the date or period from which to collect data to compare against (default: the period of the same length just before)")
+	previousUntil = flag.String("previous-until", "", "with compare, the date or period until which to collect data to compare against, inclusive")
 
 	// Optional flags.
-	gerritFlag = flag.Bool("gerrit", true, "collect data on Go issues or changelists")
-	gitHubFlag = flag.Bool("github", true, "collect data on GitHub issues")
+	gerritFlag     = flag.Bool("gerrit", true, "collect data on Go issues or changelists")
+	gitHubFlag     = flag.Bool("github", true, "collect data on GitHub issues")
+	exploratory    = flag.Bool("exploratory", false, "report draft and abandoned Go changelists in a separate tab, instead of leaving out abandoned changelists")
+	componentsFile = flag.String("components", "", "CODEOWNERS-style file mapping paths to components, by which changelists are categorized (see generic.ComponentMap)")
+	multiCategory  = flag.Bool("multi-category", false, "with -components, report changelists that span several components under each of them")
+	rulesFile      = flag.String("rules", "", "JSON file of rules that classify issues and changelists into categories, such as bug or docs (see generic.LoadRules)")
+	checkouts      = flag.String("checkouts", "", "comma-separated local checkouts, as repo=path or a path named after the repo, whose CODEOWNERS and OWNERS files decide who owns the files that Go changelists affect (see generic.Ownership)")
+	githubCache    = flag.String("github-cache", github.DefaultActivityCachePath(), "file in which to cache comments, reactions, mentions, and triage on GitHub issues and PRs between runs, or \"\" to disable caching")
 
 	// Flags relating to Google sheets exporter.
 	googleSheetsFlag = flag.String("sheets", "", "write or append output to a Google spreadsheet (either \"\", \"new\", or the URL of an existing sheet)")
 	credentialsFile  = flag.String("credentials", "", "path to credentials file for Google Sheets")
 	tokenFile        = flag.String("token", "", "path to token file for authentication in Google sheets")
+
+	// Flags relating to JSON output.
+	jsonFile = flag.String("json", "", "write all collected data as JSON to the given file")
 )
 
+// report is the JSON output of the command.
+type report struct {
+	Golang         *sourceReport                      `json:"golang,omitempty"`
+	GitHub         *sourceReport                      `json:"github,omitempty"`
+	Metrics        *generic.Metrics                   `json:"metrics,omitempty"`
+	Responsiveness map[string]*generic.Responsiveness `json:"responsiveness,omitempty"`
+}
+
+// sourceReport holds the data collected from a single source.
+type sourceReport struct {
+	Authored       []*generic.Changelist    `json:"authored"`
+	Reviewed       []*generic.Changelist    `json:"reviewed"`
+	Issues         []*generic.Issue         `json:"issues"`
+	ReviewRequests []*generic.ReviewRequest `json:"review_requests"`
+	Backports      []*generic.Changelist    `json:"backports,omitempty"`
+	Discussions    []*generic.Discussion    `json:"discussions,omitempty"`
+
+	// Ownership is only computed with -checkouts.
+	Ownership *generic.OwnershipSummary `json:"ownership,omitempty"`
+
+	// Drafts and Abandoned are only collected with -exploratory. Drafts
+	// are otherwise included in Authored.
+	Drafts    []*generic.Changelist `json:"drafts,omitempty"`
+	Abandoned []*generic.Changelist `json:"abandoned,omitempty"`
+}
+
+// comparison is the JSON output of the compare subcommand.
+type comparison struct {
+	Previous *report `json:"previous"`
+	Current  *report `json:"current"`
+}
+
 func main() {
 	flag.Parse()
 
 	// Snippets are a summary of a user's contributions over the past week.
 	snippets := flag.Arg(0) == "snippets"
 
+	// Comparisons show the change in a user's contributions between two
+	// periods, such as this quarter and last quarter.
+	compare := flag.Arg(0) == "compare"
+
 	// Username and email are required flags.
 	// If since is omitted, results reflect all history.
 	if *username == "" && *gitHubFlag {
@@ -47,31 +102,89 @@ func main() {
 	if *email == "" && *gerritFlag {
 		log.Fatal("Please provide your Gerrit email.")
 	}
+	if *since == "" && *period == "" && compare {
+		log.Fatal("Please provide -since or -period when using compare.")
+	}
 	emails := strings.Split(*email, ",")
 
-	// Parse out the start date, if provided.
-	var (
-		start, end time.Time
-		err        error
-	)
-	if *since != "" {
-		start, err = time.Parse("2006-01-02", *since)
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Fatal(err)
+	}
+	now := time.Now().In(loc)
+
+	var rules generic.Rules
+	if *rulesFile != "" {
+		if rules, err = generic.LoadRules(*rulesFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var components *generic.ComponentMap
+	if *componentsFile != "" {
+		if components, err = generic.LoadComponentMap(*componentsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var ownership *generic.Ownership
+	if *checkouts != "" {
+		repos, err := generic.ParseCheckouts(*checkouts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if ownership, err = generic.LoadOwnership(repos); err != nil {
+			log.Fatal(err)
+		}
+	}
+	identities := append([]string{*username}, emails...)
+
+	// Parse out the start and end dates, if provided.
+	var r generic.Range
+	if *period != "" {
+		r, err = generic.ParsePeriod(now, *period)
 		if err != nil {
 			log.Fatal(err)
 		}
 	} else if snippets {
 		// If we're generating a snippet report, the start date is a week ago.
-		start = time.Now().AddDate(0, 0, -7)
+		r = generic.Range{Start: now.AddDate(0, 0, -7), End: now}
 	} else {
-		start = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+		r = generic.Range{Start: time.Date(1900, time.January, 1, 0, 0, 0, 0, loc), End: now}
+	}
+	if *since != "" {
+		p, err := generic.ParsePeriod(now, *since)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r.Start = p.Start
 	}
 	if *until != "" {
-		end, err = time.Parse("2006-01-02", *until)
+		p, err := generic.ParsePeriod(now, *until)
 		if err != nil {
 			log.Fatal(err)
 		}
-	} else {
-		end = time.Now()
+		r.End = p.End
+	}
+
+	// By default, compare against the period of the same length that ends
+	// where the current one starts.
+	var previous generic.Range
+	if compare {
+		previous.End = r.Start
+		if *previousUntil != "" {
+			p, err := generic.ParsePeriod(now, *previousUntil)
+			if err != nil {
+				log.Fatal(err)
+			}
+			previous.End = p.End
+		}
+		previous.Start = previous.End.Add(-r.End.Sub(r.Start))
+		if *previousSince != "" {
+			p, err := generic.ParsePeriod(now, *previousSince)
+			if err != nil {
+				log.Fatal(err)
+			}
+			previous.Start = p.Start
+		}
 	}
 
 	// Determine if the user has provided a valid Google Sheets URL.
@@ -93,48 +206,90 @@ func main() {
 	ctx := context.Background()
 	rowData := make(map[string][]*gsheets.RowData)
 
-	// Write out data on the user's activity on the Go project's GitHub issues
-	// and the Go project's Gerrit code reviews.
+	var (
+		corpus   *maintner.Corpus
+		accounts *golang.Accounts
+		fixes    golang.Fixes
+	)
 	if *gerritFlag {
 		// Get the corpus data (very slow on first try, uses cache after).
-		corpus, err := godata.Get(ctx)
+		corpus, err = godata.Get(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
-		issues, err := golang.Issues(corpus.GitHub(), "", *username, start, end)
+		ids, err := golang.ParseAccountIDs(*accountID)
 		if err != nil {
 			log.Fatal(err)
 		}
-		if err := sheets.Write(ctx, dir, map[string][]*sheets.Row{
-			"golang-issues": generic.IssuesToCells(*username, issues),
-		}, rowData); err != nil {
+		accounts, err = golang.ResolveAccounts(corpus.Gerrit(), emails, ids)
+		if err != nil {
 			log.Fatal(err)
 		}
-		authored, reviewed, err := golang.Changelists(corpus.Gerrit(), emails, start, end)
+		for _, d := range accounts.Diagnostics {
+			log.Print(d)
+		}
+		if len(accounts.IDs) == 0 {
+			log.Printf("No Gerrit account ID was found for %s, so reviews and review requests will not be collected. Use -gerrit-account-id to provide it.", *email)
+		}
+		fixes, err = golang.IndexFixes(corpus)
 		if err != nil {
 			log.Fatal(err)
 		}
-		if err := sheets.Write(ctx, dir, map[string][]*sheets.Row{
-			"golang-authored": generic.AuthoredChangelistsToCells(authored),
-			"golang-reviewed": generic.ReviewedChangelistsToCells(reviewed),
-		}, rowData); err != nil {
+	}
+	var (
+		client *gogithub.Client
+		cache  *github.ActivityCache
+	)
+	if *gitHubFlag {
+		client, err = github.NewClient(ctx)
+		if err != nil {
 			log.Fatal(err)
 		}
+		if *githubCache != "" {
+			cache, err = github.OpenActivityCache(*githubCache)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
 	}
 
-	// Write out data on the user's activity on GitHub issues outside of the Go project.
-	if *gitHubFlag {
-		authored, reviewed, issues, err := github.IssuesAndPRs(ctx, *username, start, end)
+	out, err := collect(ctx, corpus, accounts, fixes, client, cache, r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	classify(out, components, rules)
+	attributeOwnership(out, ownership, identities)
+	var data map[string][]*sheets.Row
+	var jsonOut interface{} = out
+	if compare {
+		previousOut, err := collect(ctx, corpus, accounts, fixes, client, cache, previous)
+		if err != nil {
+			log.Fatal(err)
+		}
+		classify(previousOut, components, rules)
+		attributeOwnership(previousOut, ownership, identities)
+		data = compareToCells(previousOut, out)
+		jsonOut = &comparison{Previous: previousOut, Current: out}
+	} else {
+		data = reportToCells(out)
+	}
+	if err := cache.Save(); err != nil {
+		log.Fatal(err)
+	}
+	if err := sheets.Write(ctx, dir, data, rowData); err != nil {
+		log.Fatal(err)
+	}
+
+	// Optionally write all of the data as JSON.
+	if *jsonFile != "" {
+		data, err := json.MarshalIndent(jsonOut, "", "\t")
 		if err != nil {
 			log.Fatal(err)
 		}
-		if err := sheets.Write(ctx, dir, map[string][]*sheets.Row{
-			"github-issues":       generic.IssuesToCells(*username, issues),
-			"github-prs-authored": generic.AuthoredChangelistsToCells(authored),
-			"github-prs-reviewed": generic.ReviewedChangelistsToCells(reviewed),
-		}, rowData); err != nil {
+		if err := ioutil.WriteFile(*jsonFile, data, 0644); err != nil {
 			log.Fatal(err)
 		}
+		log.Printf("Wrote JSON output to %s.\n", *jsonFile)
 	}
 
 	// Optionally write output to Google Sheets.
@@ -157,7 +312,10 @@ func main() {
 		if name == "" {
 			name = strings.Split(*email, "@")[0]
 		}
-		title := fmt.Sprintf("%s (as of %s)", name, start.Format("01-02-2006"))
+		title := fmt.Sprintf("%s (as of %s)", name, r.Start.Format("01-02-2006"))
+		if compare {
+			title = fmt.Sprintf("%s (%s vs. %s)", name, r.Start.Format("01-02-2006"), previous.Start.Format("01-02-2006"))
+		}
 		spreadsheet, err = sheets.CreateSheet(ctx, srv, title, rowData)
 		if err != nil {
 			log.Fatal(err)
@@ -173,3 +331,158 @@ func main() {
 	}
 	log.Printf("Wrote data to Google Sheet: %s\n", spreadsheet.SpreadsheetUrl)
 }
+
+// collect gathers the user's activity during r on the Go
+// project's GitHub issues and Gerrit code reviews, if corpus is non-nil, and
+// on other GitHub repositories, if client is non-nil. fixes index the CLs
+// that fix the Go project's issues. Comments on GitHub are cached in cache,
+// if it is non-nil.
+func collect(ctx context.Context, corpus *maintner.Corpus, accounts *golang.Accounts, fixes golang.Fixes, client *gogithub.Client, cache *github.ActivityCache, r generic.Range) (*report, error) {
+	var (
+		out      report
+		all      []*generic.Changelist
+		requests []*generic.ReviewRequest
+	)
+	if corpus != nil {
+		issues, err := golang.Issues(corpus, fixes, "", *username, accounts, r)
+		if err != nil {
+			return nil, err
+		}
+		authored, reviewed, err := golang.Changelists(corpus.Gerrit(), accounts, r)
+		if err != nil {
+			return nil, err
+		}
+		var reviewRequests []*generic.ReviewRequest
+		if len(accounts.IDs) > 0 {
+			reviewRequests, err = golang.ReviewRequests(corpus.Gerrit(), accounts, r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		backports, err := golang.Backports(corpus.Gerrit(), accounts, r)
+		if err != nil {
+			return nil, err
+		}
+		out.Golang = &sourceReport{Authored: authored, Reviewed: reviewed, Issues: issues, ReviewRequests: reviewRequests, Backports: backports}
+		if *exploratory {
+			out.Golang.Authored = nil
+			for _, cl := range authored {
+				if cl.Status == generic.Draft {
+					out.Golang.Drafts = append(out.Golang.Drafts, cl)
+				} else {
+					out.Golang.Authored = append(out.Golang.Authored, cl)
+				}
+			}
+			out.Golang.Abandoned, err = golang.Abandoned(corpus.Gerrit(), accounts, r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		all = append(all, authored...)
+		all = append(all, reviewed...)
+		all = append(all, backports...)
+		requests = append(requests, reviewRequests...)
+	}
+	if client != nil {
+		authored, reviewed, issues, err := github.IssuesAndPRs(ctx, client, cache, *username, r)
+		if err != nil {
+			return nil, err
+		}
+		reviewRequests, err := github.ReviewRequests(ctx, client, *username, r)
+		if err != nil {
+			return nil, err
+		}
+		discussions, err := github.Discussions(ctx, client, *username, r)
+		if err != nil {
+			return nil, err
+		}
+		// Report changes mirrored between Gerrit and GitHub only once. golang
+		// PRs are dropped even if Gerrit data was not collected.
+		var gerritAuthored, gerritReviewed []*generic.Changelist
+		if out.Golang != nil {
+			gerritAuthored, gerritReviewed = out.Golang.Authored, out.Golang.Reviewed
+		}
+		authored = generic.DedupChangelists(gerritAuthored, authored)
+		reviewed = generic.DedupChangelists(gerritReviewed, reviewed)
+		out.GitHub = &sourceReport{Authored: authored, Reviewed: reviewed, Issues: issues, ReviewRequests: reviewRequests, Discussions: discussions}
+		all = append(all, authored...)
+		all = append(all, reviewed...)
+		requests = append(requests, reviewRequests...)
+	}
+	// Compute time-to-review and time-to-merge metrics for all of the
+	// changelists collected above, and how quickly the user responds to
+	// review requests.
+	out.Metrics = generic.ComputeMetrics(all)
+	out.Responsiveness = generic.ComputeResponsiveness(requests)
+	return &out, nil
+}
+
+// classify assigns the changelists in the report to their components, and
+// the issues and changelists to the categories of the rules that match them.
+func classify(out *report, components *generic.ComponentMap, rules generic.Rules) {
+	for _, src := range []*sourceReport{out.Golang, out.GitHub} {
+		if src == nil {
+			continue
+		}
+		rules.ClassifyIssues(src.Issues)
+		for _, cls := range [][]*generic.Changelist{src.Authored, src.Reviewed, src.Backports, src.Drafts, src.Abandoned} {
+			components.Classify(cls, *multiCategory)
+			rules.ClassifyChangelists(cls)
+		}
+	}
+}
+
+// attributeOwnership summarizes how much of the user's Go changelists landed
+// in areas that they own, according to the identities, and in others' areas.
+// Only Gerrit changelists record the files that they affect.
+func attributeOwnership(out *report, ownership *generic.Ownership, identities []string) {
+	if ownership == nil || out.Golang == nil {
+		return
+	}
+	out.Golang.Ownership = generic.ComputeOwnership(ownership, identities, out.Golang.Authored, out.Golang.Reviewed)
+}
+
+// reportToCells lays out each tab of the report.
+func reportToCells(out *report) map[string][]*sheets.Row {
+	data := map[string][]*sheets.Row{
+		"metrics":        generic.MetricsToCells(out.Metrics),
+		"responsiveness": generic.ResponsivenessToCells(out.Responsiveness),
+	}
+	if out.Golang != nil {
+		data["golang-issues"] = generic.IssuesToCells(*username, out.Golang.Issues)
+		data["golang-authored"] = generic.AuthoredChangelistsToCells(out.Golang.Authored)
+		data["golang-reviewed"] = generic.ReviewedChangelistsToCells(out.Golang.Reviewed)
+		data["golang-triage"] = generic.TriageToCells(out.Golang.Issues)
+		data["golang-backports"] = generic.BackportsToCells(out.Golang.Backports)
+		data["golang-ownership"] = generic.OwnershipToCells(out.Golang.Ownership)
+		if *exploratory {
+			data["golang-exploratory"] = generic.ExploratoryChangelistsToCells(append(out.Golang.Drafts, out.Golang.Abandoned...))
+		}
+	}
+	if out.GitHub != nil {
+		data["github-issues"] = generic.IssuesToCells(*username, out.GitHub.Issues)
+		data["github-triage"] = generic.TriageToCells(out.GitHub.Issues)
+		data["github-discussions"] = generic.DiscussionsToCells(out.GitHub.Discussions)
+		data["github-prs-authored"] = generic.AuthoredChangelistsToCells(out.GitHub.Authored)
+		data["github-prs-reviewed"] = generic.ReviewedChangelistsToCells(out.GitHub.Reviewed)
+	}
+	return data
+}
+
+// compareToCells lays out a tab comparing each of the previous report's
+// issues, authored changelists, and reviewed changelists with the current
+// report's.
+func compareToCells(previous, current *report) map[string][]*sheets.Row {
+	data := make(map[string][]*sheets.Row)
+	if current.Golang != nil {
+		data["golang-issues-compare"] = generic.IssuesComparisonToCells(*username, previous.Golang.Issues, current.Golang.Issues)
+		data["golang-authored-compare"] = generic.AuthoredComparisonToCells(previous.Golang.Authored, current.Golang.Authored)
+		data["golang-reviewed-compare"] = generic.ReviewedComparisonToCells(previous.Golang.Reviewed, current.Golang.Reviewed)
+	}
+	if current.GitHub != nil {
+		data["github-issues-compare"] = generic.IssuesComparisonToCells(*username, previous.GitHub.Issues, current.GitHub.Issues)
+		data["github-prs-authored-compare"] = generic.AuthoredComparisonToCells(previous.GitHub.Authored, current.GitHub.Authored)
+		data["github-prs-reviewed-compare"] = generic.ReviewedComparisonToCells(previous.GitHub.Reviewed, current.GitHub.Reviewed)
+	}
+	return data
+}
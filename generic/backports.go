@@ -0,0 +1,76 @@
+package generic
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+
+	"github.com/stamblerre/sheets"
+)
+
+// IsReleaseBranch reports whether branch is a release branch, such as
+// "release-branch.go1.18" or "gopls-release-branch.0.8".
+func IsReleaseBranch(branch string) bool {
+	return strings.HasPrefix(branch, "release-branch.") || strings.Contains(branch, "-release-branch.")
+}
+
+// BackportsToCells lays out backports by release branch, with the CL that
+// each was cherry-picked from and the number of backports to each branch.
+func BackportsToCells(cls []*Changelist) []*sheets.Row {
+	if len(cls) == 0 {
+		return nil
+	}
+	type releaseBranch struct {
+		repo, branch string
+	}
+	branches := make(map[releaseBranch][]*Changelist)
+	for _, cl := range cls {
+		b := releaseBranch{repo: cl.Repo, branch: cl.Branch}
+		branches[b] = append(branches[b], cl)
+	}
+	var sortedBranches []releaseBranch
+	for b := range branches {
+		sortedBranches = append(sortedBranches, b)
+	}
+	sort.Slice(sortedBranches, func(i, j int) bool {
+		if sortedBranches[i].repo == sortedBranches[j].repo {
+			return sortedBranches[i].branch < sortedBranches[j].branch
+		}
+		return sortedBranches[i].repo < sortedBranches[j].repo
+	})
+
+	sheet := []*sheets.Row{{
+		Cells: []*sheets.Cell{
+			{Text: "CL"},
+			{Text: "Description"},
+			{Text: "Original CL"},
+			{Text: "Status"},
+		},
+		BoldText: true,
+	}}
+	for _, b := range sortedBranches {
+		cls := branches[b]
+		sort.SliceStable(cls, func(i, j int) bool {
+			return cls[i].Link < cls[j].Link
+		})
+		for _, cl := range cls {
+			var yellow color.Color
+			if cl.Status != Merged {
+				yellow = sheets.PaleYellow()
+			}
+			sheet = append(sheet, &sheets.Row{
+				Cells: []*sheets.Cell{
+					{Text: cl.Link, Hyperlink: cl.Link},
+					{Text: truncate(cl.Subject)},
+					{Text: cl.Origin, Hyperlink: cl.Origin},
+					{Text: cl.Status.String()},
+				},
+				Color: yellow,
+			})
+		}
+		sheet = append(sheet, sheets.TotalRow("Subtotal", fmt.Sprintf("%s: %s", b.repo, b.branch), fmt.Sprint(len(cls))))
+	}
+	sheet = append(sheet, sheets.TotalRow("Total", "", fmt.Sprint(len(cls))))
+	return sheet
+}
@@ -0,0 +1,49 @@
+package generic_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestIsReleaseBranch(t *testing.T) {
+	for branch, want := range map[string]bool{
+		"master":                   false,
+		"dev.boringcrypto":         false,
+		"release-branch.go1.18":    true,
+		"gopls-release-branch.0.8": true,
+	} {
+		if got := generic.IsReleaseBranch(branch); got != want {
+			t.Errorf("IsReleaseBranch(%q) = %v, want %v", branch, got, want)
+		}
+	}
+}
+
+func TestBackportsToCells(t *testing.T) {
+	cls := []*generic.Changelist{
+		{Link: "go/3", Repo: "go", Branch: "release-branch.go1.18", Subject: "[release-branch.go1.18] cmd/go: fix", Origin: "go/1", Status: generic.Merged},
+		{Link: "go/4", Repo: "go", Branch: "release-branch.go1.17", Subject: "[release-branch.go1.17] cmd/go: fix", Origin: "go/1", Status: generic.New},
+		{Link: "go/5", Repo: "go", Branch: "release-branch.go1.18", Subject: "[release-branch.go1.18] net: fix", Origin: "go/2", Status: generic.Merged},
+	}
+	var got [][]string
+	for _, row := range generic.BackportsToCells(cls) {
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Text)
+		}
+		got = append(got, cells)
+	}
+	want := [][]string{
+		{"CL", "Description", "Original CL", "Status"},
+		{"go/4", "[release-branch.go1.17] cmd/go: fix", "go/1", "new"},
+		{"Subtotal", "go: release-branch.go1.17", "1"},
+		{"go/3", "[release-branch.go1.18] cmd/go: fix", "go/1", "merged"},
+		{"go/5", "[release-branch.go1.18] net: fix", "go/2", "merged"},
+		{"Subtotal", "go: release-branch.go1.18", "2"},
+		{"Total", "", "3"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rows (-want +got):\n%s", diff)
+	}
+}
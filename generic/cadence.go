@@ -0,0 +1,141 @@
+package generic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval is the length of each period in a Cadence.
+type Interval int
+
+const (
+	Weekly = Interval(iota)
+	Biweekly
+	Monthly
+)
+
+func (i Interval) String() string {
+	switch i {
+	case Weekly:
+		return "weekly"
+	case Biweekly:
+		return "biweekly"
+	case Monthly:
+		return "monthly"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseInterval parses "weekly", "biweekly", or "monthly".
+func ParseInterval(s string) (Interval, error) {
+	for _, i := range []Interval{Weekly, Biweekly, Monthly} {
+		if strings.EqualFold(s, i.String()) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown interval %q (want weekly, biweekly, or monthly)", s)
+}
+
+// ParseWeekday parses the name of a day of the week, such as "Sunday" or
+// "sun".
+func ParseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day of the week %q", s)
+}
+
+// Cadence describes how often snippets are written and which period a
+// snippet written on a given day reports on.
+type Cadence struct {
+	Interval Interval
+
+	// WeekStart is the first day of each weekly period.
+	WeekStart time.Weekday
+
+	// Cutoff is the last day of the week on which snippets are still
+	// written for the previous period. Snippets written from the start of a
+	// period through the first Cutoff day are for the previous period, and
+	// later ones are for the current period.
+	Cutoff time.Weekday
+
+	// Anchor is the first day of any one biweekly period, such as the start
+	// of a sprint. Every other biweekly period is a multiple of two weeks
+	// before or after it.
+	Anchor time.Time
+}
+
+// DefaultCadence is weekly, with weeks starting on Monday. Snippets written
+// from Monday through Wednesday are for the previous week.
+var DefaultCadence = Cadence{
+	Interval:  Weekly,
+	WeekStart: time.Monday,
+	Cutoff:    time.Wednesday,
+}
+
+// InferTimeRange gets the period for the snippet report written at now. If
+// the optional date parameter is provided, the period is the one in which
+// date starts, not inferred. date may be any period accepted by ParsePeriod.
+func (c Cadence) InferTimeRange(now time.Time, date string) (Range, error) {
+	if c.Interval == Biweekly && c.Anchor.IsZero() {
+		return Range{}, fmt.Errorf("biweekly cadence requires an anchor date")
+	}
+	if date != "" {
+		period, err := ParsePeriod(now, date)
+		if err != nil {
+			return Range{}, err
+		}
+		return c.periodOf(midnight(period.Start)), nil
+	}
+	today := midnight(now)
+	current := c.periodOf(today)
+	cutoff := current.Start
+	for cutoff.Weekday() != c.Cutoff {
+		cutoff = cutoff.AddDate(0, 0, 1)
+	}
+	if !today.After(cutoff) {
+		return c.periodOf(current.Start.AddDate(0, 0, -1)), nil
+	}
+	return current, nil
+}
+
+// periodOf returns the period that contains day, which is at midnight.
+func (c Cadence) periodOf(day time.Time) Range {
+	switch c.Interval {
+	case Monthly:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return Range{Start: start, End: start.AddDate(0, 1, 0)}
+	case Biweekly:
+		anchor := c.Anchor.In(day.Location())
+		anchor = time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, day.Location())
+		n := daysBetween(anchor, day)
+		// Round down, even before the anchor.
+		n -= ((n % 14) + 14) % 14
+		start := anchor.AddDate(0, 0, n)
+		return Range{Start: start, End: start.AddDate(0, 0, 14)}
+	default:
+		start := day
+		for start.Weekday() != c.WeekStart {
+			start = start.AddDate(0, 0, -1)
+		}
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}
+	}
+}
+
+// midnight returns the start of t's day, in t's location.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween returns the number of calendar days from a to b. Days are
+// counted on the calendar, rather than in hours, so that days on which
+// daylight saving time begins or ends still count as one day.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
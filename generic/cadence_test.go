@@ -0,0 +1,110 @@
+package generic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestCadenceInferTimeRange(t *testing.T) {
+	sundays := generic.Cadence{
+		Interval:  generic.Weekly,
+		WeekStart: time.Sunday,
+		Cutoff:    time.Tuesday,
+	}
+	sprints := generic.Cadence{
+		Interval: generic.Biweekly,
+		// A Sunday.
+		Anchor: time.Date(2020, time.March, 29, 0, 0, 0, 0, time.UTC),
+		Cutoff: time.Monday,
+	}
+	monthly := generic.Cadence{
+		Interval: generic.Monthly,
+		Cutoff:   time.Wednesday,
+	}
+	for _, tt := range []struct {
+		name        string
+		cadence     generic.Cadence
+		date, date2 string
+		start, end  string
+		wantErr     bool
+	}{
+		{name: "Sunday weeks, Sunday", cadence: sundays, date: "2020-04-19", start: "2020-04-12", end: "2020-04-19"},
+		{name: "Sunday weeks, Tuesday", cadence: sundays, date: "2020-04-21", start: "2020-04-12", end: "2020-04-19"},
+		{name: "Sunday weeks, Wednesday", cadence: sundays, date: "2020-04-22", start: "2020-04-19", end: "2020-04-26"},
+		{name: "Sunday weeks, Saturday", cadence: sundays, date: "2020-04-25", start: "2020-04-19", end: "2020-04-26"},
+		{name: "Sunday weeks, explicit", cadence: sundays, date: "2020-04-20", date2: "2020-04-30", start: "2020-04-26", end: "2020-05-03"},
+
+		{name: "sprint, first Monday", cadence: sprints, date: "2020-04-13", start: "2020-03-29", end: "2020-04-12"},
+		{name: "sprint, first Tuesday", cadence: sprints, date: "2020-04-14", start: "2020-04-12", end: "2020-04-26"},
+		{name: "sprint, second week", cadence: sprints, date: "2020-04-21", start: "2020-04-12", end: "2020-04-26"},
+		{name: "sprint, before anchor", cadence: sprints, date: "2020-03-20", start: "2020-03-15", end: "2020-03-29"},
+		{name: "sprint, explicit", cadence: sprints, date: "2020-04-13", date2: "2020-05-10", start: "2020-05-10", end: "2020-05-24"},
+		{name: "sprint without anchor", cadence: generic.Cadence{Interval: generic.Biweekly}, date: "2020-04-13", wantErr: true},
+
+		// April 1, 2020 was a Wednesday.
+		{name: "monthly, first Wednesday", cadence: monthly, date: "2020-04-01", start: "2020-03-01", end: "2020-04-01"},
+		{name: "monthly, first Thursday", cadence: monthly, date: "2020-04-02", start: "2020-04-01", end: "2020-05-01"},
+		{name: "monthly, end of month", cadence: monthly, date: "2020-04-30", start: "2020-04-01", end: "2020-05-01"},
+		{name: "monthly, explicit", cadence: monthly, date: "2020-04-02", date2: "2020-Q1", start: "2020-01-01", end: "2020-02-01"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse("2006-01-02", tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := tt.cadence.InferTimeRange(now.Add(9*time.Hour), tt.date2)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want error", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Start.Format("2006-01-02"); got != tt.start {
+				t.Errorf("got start %s, want %s", got, tt.start)
+			}
+			if got := r.End.Format("2006-01-02"); got != tt.end {
+				t.Errorf("got end %s, want %s", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestCadenceDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Daylight saving time began during the sprint that started on
+	// Sunday, March 1, 2020, so later sprints must still start at midnight.
+	c := generic.Cadence{
+		Interval: generic.Biweekly,
+		Anchor:   time.Date(2020, time.March, 1, 0, 0, 0, 0, loc),
+		Cutoff:   time.Monday,
+	}
+	r, err := c.InferTimeRange(time.Date(2020, time.March, 20, 12, 0, 0, 0, loc), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2020, time.March, 15, 0, 0, 0, 0, loc); !r.Start.Equal(want) {
+		t.Errorf("got start %s, want %s", r.Start, want)
+	}
+	if want := time.Date(2020, time.March, 29, 0, 0, 0, 0, loc); !r.End.Equal(want) {
+		t.Errorf("got end %s, want %s", r.End, want)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for _, s := range []string{"Sunday", "sunday", "SUN"} {
+		if d, err := generic.ParseWeekday(s); err != nil || d != time.Sunday {
+			t.Errorf("ParseWeekday(%q) = %v, %v, want Sunday", s, d, err)
+		}
+	}
+	if _, err := generic.ParseWeekday("someday"); err == nil {
+		t.Errorf("ParseWeekday(%q) succeeded, want error", "someday")
+	}
+}
@@ -3,7 +3,7 @@ package generic
 import (
 	"fmt"
 	"image/color"
-	"path/filepath"
+	"path"
 	"sort"
 	"strings"
 	"time"
@@ -21,9 +21,36 @@ type Changelist struct {
 	Author           string
 	Repo             string
 	Status           ChangelistStatus
+	CreatedAt        time.Time
+	FirstReviewAt    time.Time
 	MergedAt         time.Time
 	AssociatedIssues []*Issue
 	AffectedFiles    []string
+
+	// Patchsets is the number of patch sets uploaded for a Gerrit CL, or the
+	// number of commits in a GitHub PR.
+	Patchsets    int
+	FilesChanged int
+	LinesAdded   int
+	LinesDeleted int
+
+	// Mirror is the link to the same change in another source, such as the
+	// GitHub PR that a Gerrit CL was imported from. See DedupChangelists.
+	Mirror string
+
+	// Origin is the link to the CL that a backport was cherry-picked from,
+	// if it is known.
+	Origin string
+
+	// UserComments is the number of comments, including review comments,
+	// that the user left on a GitHub PR during the reporting period.
+	UserComments int
+
+	// Class is the category assigned to the changelist by Rules, if any.
+	Class string
+	// Components are the components that the changelist is reported under,
+	// as assigned by a ComponentMap, if any.
+	Components []string
 }
 
 type ChangelistStatus int
@@ -51,35 +78,97 @@ func (status ChangelistStatus) String() string {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler, so that statuses are
+// readable in JSON output.
+func (status ChangelistStatus) MarshalText() ([]byte, error) {
+	return []byte(status.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (status *ChangelistStatus) UnmarshalText(text []byte) error {
+	for _, s := range []ChangelistStatus{Abandoned, Draft, New, Merged} {
+		if s.String() == string(text) {
+			*status = s
+			return nil
+		}
+	}
+	*status = Unknown
+	return nil
+}
+
+// TimeToFirstReview reports how long the CL waited for its first review.
+// The boolean is false if the CL has not been reviewed.
+func (cl *Changelist) TimeToFirstReview() (time.Duration, bool) {
+	if cl.CreatedAt.IsZero() || cl.FirstReviewAt.IsZero() {
+		return 0, false
+	}
+	return cl.FirstReviewAt.Sub(cl.CreatedAt), true
+}
+
+// TimeToMerge reports how long the CL took to be merged after it was
+// created. The boolean is false if the CL has not been merged.
+func (cl *Changelist) TimeToMerge() (time.Duration, bool) {
+	if cl.Status != Merged || cl.CreatedAt.IsZero() || cl.MergedAt.IsZero() {
+		return 0, false
+	}
+	return cl.MergedAt.Sub(cl.CreatedAt), true
+}
+
+// rootCategory is the category of changelists that only affect files at the
+// root of their repository, such as go.mod.
+const rootCategory = "(root)"
+
+// Category returns the changelist's Class, if it has one, or else its first
+// component, or else the prefix of its subject, or else the directory most of
+// its files are in.
 func (cl *Changelist) Category() string {
+	if cl.Class != "" {
+		return cl.Class
+	}
+	if len(cl.Components) > 0 {
+		return cl.Components[0]
+	}
 	if category := extractCategory(cl.Subject); category != "" {
 		return category
 	}
 	// No category in the CL description. Check the affected files.
 	// Determine the longest and most popular parent directory and choose that
-	// as the category.
+	// as the category, breaking ties by name.
 	directories := map[string]int{}
 	for _, filename := range cl.AffectedFiles {
-		dir := filepath.Dir(filename)
-		for dir != filepath.Dir(dir) {
+		dir := path.Dir(filename)
+		for dir != path.Dir(dir) {
 			directories[dir]++
-			dir = filepath.Dir(dir)
+			dir = path.Dir(dir)
 		}
 	}
 	var popularDir string
 	var popularCount int
 	for dir, count := range directories {
-		if count < popularCount {
-			continue
-		}
-		if count > popularCount || len(dir) > len(popularDir) {
+		switch {
+		case count < popularCount:
+		case count > popularCount,
+			len(dir) > len(popularDir),
+			len(dir) == len(popularDir) && dir < popularDir:
 			popularCount = count
 			popularDir = dir
 		}
 	}
+	if popularDir == "" && len(cl.AffectedFiles) > 0 {
+		return rootCategory
+	}
 	return popularDir
 }
 
+// Categories returns the categories that the changelist is reported under:
+// each of its Components, if it has several, or else its Category.
+func (cl *Changelist) Categories() []string {
+	if cl.Class == "" && len(cl.Components) > 1 {
+		return cl.Components
+	}
+	return []string{cl.Category()}
+}
+
 type category struct {
 	branch string
 	desc   string
@@ -110,6 +199,12 @@ func AuthoredChangelistsToCells(cls []*Changelist) []*sheets.Row {
 		Cells: []*sheets.Cell{
 			{Text: "CL"},
 			{Text: "Description"},
+			{Text: ""},
+			{Text: "Patchsets"},
+			{Text: "Files Changed"},
+			{Text: "Lines Added"},
+			{Text: "Lines Deleted"},
+			{Text: "Mirror"},
 		},
 		BoldText: true,
 	}}
@@ -117,11 +212,13 @@ func AuthoredChangelistsToCells(cls []*Changelist) []*sheets.Row {
 		cls := repos[repo]
 		categories := make(map[category][]*Changelist)
 		for _, cl := range cls {
-			c := category{
-				branch: cl.Branch,
-				desc:   cl.Category(),
+			for _, desc := range cl.Categories() {
+				c := category{
+					branch: cl.Branch,
+					desc:   desc,
+				}
+				categories[c] = append(categories[c], cl)
 			}
-			categories[c] = append(categories[c], cl)
 		}
 		var sortedCategories []category
 		for category := range categories {
@@ -139,30 +236,95 @@ func AuthoredChangelistsToCells(cls []*Changelist) []*sheets.Row {
 				return cls[i].Link < cls[j].Link
 			})
 			for _, cl := range cls {
-				var yellow color.Color
-				if cl.Status != Merged {
-					yellow = sheets.PaleYellow()
-				}
 				sheet = append(sheet, &sheets.Row{
 					Cells: []*sheets.Cell{
 						{Text: cl.Link, Hyperlink: cl.Link},
 						{Text: truncate(cl.Subject)},
 						{Text: ""},
+						{Text: fmt.Sprint(cl.Patchsets)},
+						{Text: fmt.Sprint(cl.FilesChanged)},
+						{Text: fmt.Sprint(cl.LinesAdded)},
+						{Text: fmt.Sprint(cl.LinesDeleted)},
+						{Text: cl.MirrorRef(), Hyperlink: cl.Mirror},
 					},
-					Color: yellow,
+					Color: statusColor(cl.Status),
 				})
 			}
 			// Only add subtotals for categories only if they are legitimate.
 			if len(sortedCategories) > 1 {
-				sheet = append(sheet, sheets.TotalRow("", category.String(), fmt.Sprint(len(cls))))
+				sheet = append(sheet, sheets.TotalRow(append([]string{"", category.String(), fmt.Sprint(len(cls))}, churn(cls)...)...))
 			}
 		}
-		sheet = append(sheet, sheets.TotalRow("Subtotal", repo, fmt.Sprint(len(repos[repo]))))
+		sheet = append(sheet, sheets.TotalRow(append([]string{"Subtotal", repo, fmt.Sprint(len(repos[repo]))}, churn(repos[repo])...)...))
+	}
+	sheet = append(sheet, sheets.TotalRow(append([]string{"Total", "", fmt.Sprintf("%v", len(cls))}, churn(cls)...)...))
+	return sheet
+}
+
+// ExploratoryChangelistsToCells lays out draft and abandoned changelists,
+// such as prototypes, in separate sections, each laid out like
+// AuthoredChangelistsToCells.
+func ExploratoryChangelistsToCells(cls []*Changelist) []*sheets.Row {
+	var drafts, abandoned []*Changelist
+	for _, cl := range cls {
+		switch cl.Status {
+		case Draft:
+			drafts = append(drafts, cl)
+		case Abandoned:
+			abandoned = append(abandoned, cl)
+		}
+	}
+	var sheet []*sheets.Row
+	for _, section := range []struct {
+		title string
+		cls   []*Changelist
+	}{
+		{"Drafts", drafts},
+		{"Abandoned", abandoned},
+	} {
+		if len(section.cls) == 0 {
+			continue
+		}
+		if len(sheet) > 0 {
+			sheet = append(sheet, &sheets.Row{})
+		}
+		sheet = append(sheet, &sheets.Row{
+			Cells:    []*sheets.Cell{{Text: section.title}},
+			BoldText: true,
+		})
+		sheet = append(sheet, AuthoredChangelistsToCells(section.cls)...)
 	}
-	sheet = append(sheet, sheets.TotalRow("Total", "", fmt.Sprintf("%v", len(cls))))
 	return sheet
 }
 
+// statusColor returns the color of a row for a changelist with the given
+// status: none for merged changelists, and pale colors for the others.
+func statusColor(status ChangelistStatus) color.Color {
+	switch status {
+	case Merged:
+		return nil
+	case Abandoned:
+		return &color.RGBA{R: 244, G: 204, B: 204, A: 255}
+	case Draft:
+		return &color.RGBA{R: 207, G: 226, B: 243, A: 255}
+	default:
+		return sheets.PaleYellow()
+	}
+}
+
+// churn returns the total patch sets, files changed, and lines added and
+// deleted across cls, formatted as cells.
+func churn(cls []*Changelist) []string {
+	var patchsets, files, added, deleted int
+	for _, cl := range cls {
+		patchsets += cl.Patchsets
+		files += cl.FilesChanged
+		added += cl.LinesAdded
+		deleted += cl.LinesDeleted
+	}
+	return []string{fmt.Sprint(patchsets), fmt.Sprint(files), fmt.Sprint(added), fmt.Sprint(deleted)}
+}
+
 func ReviewedChangelistsToCells(cls []*Changelist) []*sheets.Row {
 	if len(cls) == 0 {
 		return nil
@@ -181,6 +343,8 @@ func ReviewedChangelistsToCells(cls []*Changelist) []*sheets.Row {
 		Cells: []*sheets.Cell{
 			{Text: "CL"},
 			{Text: "Description"},
+			{Text: ""},
+			{Text: "Mirror"},
 		},
 		BoldText: true,
 	}}
@@ -206,6 +370,7 @@ func ReviewedChangelistsToCells(cls []*Changelist) []*sheets.Row {
 					{Text: cl.Link, Hyperlink: cl.Link},
 					{Text: truncate(cl.Subject)},
 					{Text: ""},
+					{Text: cl.MirrorRef(), Hyperlink: cl.Mirror},
 				}})
 			}
 			cells = append(cells, sheets.TotalRow("", author, fmt.Sprint(len(cls))))
@@ -1,8 +1,11 @@
 package generic_test
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/sheets"
 	"github.com/stamblerre/work-stats/generic"
 )
 
@@ -24,6 +27,35 @@ func TestExtractCategory(t *testing.T) {
 			},
 		},
 		want: "internal/lsp",
+	}, {
+		// Ties are broken by name, rather than by map iteration order.
+		cl: &generic.Changelist{
+			AffectedFiles: []string{"net/dial.go", "fmt/print.go"},
+		},
+		want: "fmt",
+	}, {
+		cl: &generic.Changelist{
+			AffectedFiles: []string{"go.mod", "go.sum"},
+		},
+		want: "(root)",
+	}, {
+		cl:   &generic.Changelist{},
+		want: "",
+	}, {
+		// Components take precedence over the subject.
+		cl: &generic.Changelist{
+			Subject:    "internal/lsp: fix hover",
+			Components: []string{"gopls", "docs"},
+		},
+		want: "gopls",
+	}, {
+		// Rules take precedence over everything else.
+		cl: &generic.Changelist{
+			Subject:    "internal/lsp: fix hover",
+			Class:      "bug",
+			Components: []string{"gopls"},
+		},
+		want: "bug",
 	}} {
 		got := tt.cl.Category()
 		if got != tt.want {
@@ -31,3 +63,70 @@ func TestExtractCategory(t *testing.T) {
 		}
 	}
 }
+
+func TestAuthoredChangelistsToCellsChurn(t *testing.T) {
+	cls := []*generic.Changelist{
+		{Link: "a/1", Repo: "tools", Subject: "gopls: one", Status: generic.Merged, Patchsets: 2, FilesChanged: 1, LinesAdded: 10, LinesDeleted: 2},
+		{Link: "a/2", Repo: "tools", Subject: "gopls: two", Status: generic.Merged, Patchsets: 1, FilesChanged: 3, LinesAdded: 5},
+		{Link: "b/1", Repo: "go", Subject: "cmd/go: three", Status: generic.New, Patchsets: 4, FilesChanged: 2, LinesAdded: 7, LinesDeleted: 30},
+	}
+	rows := generic.AuthoredChangelistsToCells(cls)
+	var got []string
+	for _, cell := range rows[len(rows)-1].Cells {
+		got = append(got, cell.Text)
+	}
+	want := []string{"Total", "", "3", "7", "6", "22", "32"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected total row: %s", diff)
+	}
+}
+
+func TestChangelistsToCellsMirror(t *testing.T) {
+	cls := []*generic.Changelist{
+		{Link: "a/1", Repo: "tools", Subject: "gopls: one", Status: generic.Merged, Mirror: "https://github.com/golang/tools/pull/12"},
+		{Link: "a/2", Repo: "tools", Subject: "gopls: two", Status: generic.Merged},
+	}
+	for name, rows := range map[string][]*sheets.Row{
+		"authored": generic.AuthoredChangelistsToCells(cls),
+		"reviewed": generic.ReviewedChangelistsToCells(cls),
+	} {
+		got := map[string]string{}
+		for _, row := range rows {
+			if len(row.Cells) == 0 || !strings.HasPrefix(row.Cells[0].Text, "a/") {
+				continue
+			}
+			mirror := row.Cells[len(row.Cells)-1]
+			got[row.Cells[0].Text] = mirror.Text + " " + mirror.Hyperlink
+		}
+		want := map[string]string{
+			"a/1": "golang/tools#12 https://github.com/golang/tools/pull/12",
+			"a/2": " ",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: unexpected mirrors (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestExploratoryChangelistsToCells(t *testing.T) {
+	cls := []*generic.Changelist{
+		{Link: "a/1", Repo: "tools", Subject: "gopls: prototype", Status: generic.Draft, Patchsets: 1, LinesAdded: 100},
+		{Link: "a/2", Repo: "tools", Subject: "gopls: try something", Status: generic.Abandoned, Patchsets: 3, LinesAdded: 5},
+	}
+	var got []string
+	for _, row := range generic.ExploratoryChangelistsToCells(cls) {
+		var first string
+		if len(row.Cells) > 0 {
+			first = row.Cells[0].Text
+		}
+		got = append(got, first)
+	}
+	want := []string{
+		"Drafts", "CL", "a/1", "Subtotal", "Total",
+		"",
+		"Abandoned", "CL", "a/2", "Subtotal", "Total",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rows: %s", diff)
+	}
+}
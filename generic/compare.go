@@ -0,0 +1,174 @@
+package generic
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stamblerre/sheets"
+)
+
+// countKey identifies the repository and category that a set of counts
+// belongs to.
+type countKey struct {
+	repo, category string
+}
+
+// AuthoredComparisonToCells compares the changelists authored in a previous
+// period with those authored in the current one, by repository and category.
+func AuthoredComparisonToCells(previous, current []*Changelist) []*sheets.Row {
+	count := func(cls []*Changelist) map[countKey][]int {
+		counts := make(map[countKey][]int)
+		for _, cl := range cls {
+			key := countKey{cl.Repo, category{branch: cl.Branch, desc: cl.Category()}.String()}
+			c, ok := counts[key]
+			if !ok {
+				c = make([]int, 3)
+				counts[key] = c
+			}
+			c[0]++
+			c[1] += cl.LinesAdded
+			c[2] += cl.LinesDeleted
+		}
+		return counts
+	}
+	return comparisonToCells([]string{"CLs", "Lines Added", "Lines Deleted"}, count(previous), count(current))
+}
+
+// ReviewedComparisonToCells compares the changelists reviewed in a previous
+// period with those reviewed in the current one, by repository and category.
+func ReviewedComparisonToCells(previous, current []*Changelist) []*sheets.Row {
+	count := func(cls []*Changelist) map[countKey][]int {
+		counts := make(map[countKey][]int)
+		for _, cl := range cls {
+			key := countKey{cl.Repo, category{branch: cl.Branch, desc: cl.Category()}.String()}
+			if counts[key] == nil {
+				counts[key] = make([]int, 1)
+			}
+			counts[key][0]++
+		}
+		return counts
+	}
+	return comparisonToCells([]string{"CLs"}, count(previous), count(current))
+}
+
+// IssuesComparisonToCells compares the user's activity on issues in a
+// previous period with their activity in the current one, by repository and
+// category. The columns match the opened, closed, comment, and issue totals
+// reported by IssuesToCells.
+func IssuesComparisonToCells(username string, previous, current []*Issue) []*sheets.Row {
+	count := func(issues []*Issue) map[countKey][]int {
+		totals := make(map[countKey]*issueTotal)
+		for _, issue := range issues {
+			key := countKey{issue.Repo, issue.Category()}
+			t, ok := totals[key]
+			if !ok {
+				t = &issueTotal{}
+				totals[key] = t
+			}
+			t.issues++
+			t.comments += issue.Comments
+			if issue.OpenedByUser(username) {
+				t.opened++
+			}
+			if issue.ClosedByUser(username) {
+				t.closed++
+			}
+		}
+		counts := make(map[countKey][]int)
+		for key, t := range totals {
+			counts[key] = []int{t.opened, t.closed, t.comments, t.issues}
+		}
+		return counts
+	}
+	return comparisonToCells([]string{"Opened", "Closed", "Comments", "Issues"}, count(previous), count(current))
+}
+
+// comparisonToCells lays out the named counts for each repository and
+// category in both periods, along with the change between them. Like the
+// other tabs, it has a subtotal for each repository if there are several, and
+// a total.
+func comparisonToCells(names []string, previous, current map[countKey][]int) []*sheets.Row {
+	if len(previous) == 0 && len(current) == 0 {
+		return nil
+	}
+	header := &sheets.Row{
+		Cells:    []*sheets.Cell{{Text: "Repository"}, {Text: "Category"}},
+		BoldText: true,
+	}
+	for _, name := range names {
+		header.Cells = append(header.Cells,
+			&sheets.Cell{Text: name + " (Previous)"},
+			&sheets.Cell{Text: name + " (Current)"},
+			&sheets.Cell{Text: name + " (Change)"},
+		)
+	}
+	repos := make(map[string][]string)
+	seen := make(map[countKey]bool)
+	for _, counts := range []map[countKey][]int{previous, current} {
+		for key := range counts {
+			if !seen[key] {
+				seen[key] = true
+				repos[key.repo] = append(repos[key.repo], key.category)
+			}
+		}
+	}
+	var sortedRepos []string
+	for repo := range repos {
+		sortedRepos = append(sortedRepos, repo)
+	}
+	sort.Strings(sortedRepos)
+
+	// values returns the cells for the given previous and current counts.
+	values := func(p, c []int) []string {
+		var cells []string
+		for i := range names {
+			cells = append(cells, fmt.Sprint(p[i]), fmt.Sprint(c[i]), formatChange(c[i]-p[i]))
+		}
+		return cells
+	}
+	add := func(total, counts []int) {
+		for i, n := range counts {
+			total[i] += n
+		}
+	}
+	rows := []*sheets.Row{header}
+	totalPrevious, totalCurrent := make([]int, len(names)), make([]int, len(names))
+	for _, repo := range sortedRepos {
+		categories := repos[repo]
+		sort.Strings(categories)
+		repoPrevious, repoCurrent := make([]int, len(names)), make([]int, len(names))
+		for _, category := range categories {
+			key := countKey{repo, category}
+			p, c := previous[key], current[key]
+			if p == nil {
+				p = make([]int, len(names))
+			}
+			if c == nil {
+				c = make([]int, len(names))
+			}
+			row := &sheets.Row{Cells: []*sheets.Cell{{Text: repo}, {Text: category}}}
+			for _, v := range values(p, c) {
+				row.Cells = append(row.Cells, &sheets.Cell{Text: v})
+			}
+			rows = append(rows, row)
+			add(repoPrevious, p)
+			add(repoCurrent, c)
+		}
+		if len(repos) > 1 {
+			rows = append(rows, sheets.TotalRow(append([]string{"Subtotal", repo}, values(repoPrevious, repoCurrent)...)...))
+		}
+		add(totalPrevious, repoPrevious)
+		add(totalCurrent, repoCurrent)
+	}
+	rows = append(rows, sheets.TotalRow(append([]string{"Total", ""}, values(totalPrevious, totalCurrent)...)...))
+	return rows
+}
+
+// formatChange formats a difference between two counts with its sign, such
+// as "+3" or "-2".
+func formatChange(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,68 @@
+package generic_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestIssuesComparisonToCells(t *testing.T) {
+	previous := []*generic.Issue{
+		{Link: "a/1", Repo: "golang/go", Title: "cmd/go: one", OpenedBy: "alice", Comments: 2},
+		{Link: "a/2", Repo: "golang/go", Title: "gopls: two", ClosedBy: "alice", Comments: 1},
+	}
+	current := []*generic.Issue{
+		{Link: "a/3", Repo: "golang/go", Title: "gopls: three", OpenedBy: "alice", ClosedBy: "alice", Comments: 4},
+		{Link: "b/1", Repo: "golang/vscode-go", Title: "debug: four", Comments: 1},
+	}
+	rows := generic.IssuesComparisonToCells("alice", previous, current)
+	var got [][]string
+	for _, row := range rows {
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Text)
+		}
+		got = append(got, cells)
+	}
+	want := [][]string{
+		{
+			"Repository", "Category",
+			"Opened (Previous)", "Opened (Current)", "Opened (Change)",
+			"Closed (Previous)", "Closed (Current)", "Closed (Change)",
+			"Comments (Previous)", "Comments (Current)", "Comments (Change)",
+			"Issues (Previous)", "Issues (Current)", "Issues (Change)",
+		},
+		{"golang/go", "cmd/go", "1", "0", "-1", "0", "0", "0", "2", "0", "-2", "1", "0", "-1"},
+		{"golang/go", "gopls", "0", "1", "+1", "1", "1", "0", "1", "4", "+3", "1", "1", "0"},
+		{"Subtotal", "golang/go", "1", "1", "0", "1", "1", "0", "3", "4", "+1", "2", "1", "-1"},
+		{"golang/vscode-go", "debug", "0", "0", "0", "0", "0", "0", "0", "1", "+1", "0", "1", "+1"},
+		{"Subtotal", "golang/vscode-go", "0", "0", "0", "0", "0", "0", "0", "1", "+1", "0", "1", "+1"},
+		{"Total", "", "1", "1", "0", "1", "1", "0", "3", "5", "+2", "2", "2", "0"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rows (-want +got):\n%s", diff)
+	}
+}
+
+func TestAuthoredComparisonToCells(t *testing.T) {
+	previous := []*generic.Changelist{
+		{Link: "a/1", Repo: "tools", Subject: "gopls: one", LinesAdded: 10, LinesDeleted: 2},
+	}
+	current := []*generic.Changelist{
+		{Link: "a/2", Repo: "tools", Subject: "gopls: two", LinesAdded: 5},
+		{Link: "a/3", Repo: "tools", Subject: "gopls: three", LinesAdded: 1, LinesDeleted: 7},
+	}
+	rows := generic.AuthoredComparisonToCells(previous, current)
+	var got []string
+	for _, cell := range rows[len(rows)-1].Cells {
+		got = append(got, cell.Text)
+	}
+	want := []string{"Total", "", "1", "2", "+1", "10", "6", "-4", "2", "7", "+5"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected total row: %s", diff)
+	}
+	if rows := generic.AuthoredComparisonToCells(nil, nil); rows != nil {
+		t.Errorf("expected no rows for empty periods, got %d", len(rows))
+	}
+}
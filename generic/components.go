@@ -0,0 +1,168 @@
+package generic
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ComponentMap maps the files in a repository to named components, such as
+// "gopls" for the files in gopls/ and internal/lsp/. Like a CODEOWNERS file,
+// each line has a pattern and a component, and the last line that matches a
+// file decides its component:
+//
+//	# Lines before any section apply to every repository.
+//	*.md              docs
+//
+//	[golang/tools]
+//	internal/lsp/     gopls
+//	gopls/            gopls
+//	gopls/doc/        docs
+//
+// A pattern is a path relative to the root of the repository. It matches the
+// file at that path, or any file in the directory at that path. A pattern with
+// wildcards, such as "*.md" or "src/cmd/*", is matched as a whole with
+// path.Match, and also against the file's name if it has no slash. Lines in a
+// section headed "[owner/repo]" only apply to that repository, including to
+// Gerrit CLs, which record only the project, such as "tools" for
+// golang/tools.
+type ComponentMap struct {
+	lines []*componentLine
+}
+
+type componentLine struct {
+	repo      string // empty for every repository
+	pattern   string
+	component string
+}
+
+// LoadComponentMap reads a component map from a file.
+func LoadComponentMap(filename string) (*ComponentMap, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseComponentMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return m, nil
+}
+
+// ParseComponentMap parses a component map in the format described by
+// ComponentMap.
+func ParseComponentMap(data []byte) (*ComponentMap, error) {
+	m := &ComponentMap{}
+	var repo string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.Count(line, "/") != 1 {
+				return nil, fmt.Errorf("line %d: invalid section %q, want [owner/repo]", n, line)
+			}
+			repo = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: %q has no component", n, line)
+		}
+		pattern := strings.Trim(fields[0], "/")
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %v", n, fields[0], err)
+		}
+		m.lines = append(m.lines, &componentLine{
+			repo:      repo,
+			pattern:   pattern,
+			component: strings.Join(fields[1:], " "),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Component returns the component of a file in the repository, or "" if no
+// line of the map matches it.
+func (m *ComponentMap) Component(repo, file string) string {
+	if m == nil {
+		return ""
+	}
+	for i := len(m.lines) - 1; i >= 0; i-- {
+		if l := m.lines[i]; (l.repo == "" || sameRepo(l.repo, repo)) && l.matches(file) {
+			return l.component
+		}
+	}
+	return ""
+}
+
+func (l *componentLine) matches(file string) bool {
+	if l.pattern == "" {
+		// A pattern of "/" matches the whole repository.
+		return true
+	}
+	if strings.ContainsAny(l.pattern, "*?[") {
+		if ok, _ := path.Match(l.pattern, file); ok {
+			return true
+		}
+		if !strings.Contains(l.pattern, "/") {
+			ok, _ := path.Match(l.pattern, path.Base(file))
+			return ok
+		}
+		return false
+	}
+	return file == l.pattern || strings.HasPrefix(file, l.pattern+"/")
+}
+
+// Components returns the components of the files that the changelist
+// affects, ordered from the one with the most files to the one with the
+// fewest, with ties in the order of their names.
+func (m *ComponentMap) Components(cl *Changelist) []string {
+	counts := make(map[string]int)
+	for _, file := range cl.AffectedFiles {
+		if c := m.Component(cl.Repo, file); c != "" {
+			counts[c]++
+		}
+	}
+	var components []string
+	for c := range counts {
+		components = append(components, c)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		ci, cj := components[i], components[j]
+		if counts[ci] != counts[cj] {
+			return counts[ci] > counts[cj]
+		}
+		return ci < cj
+	})
+	return components
+}
+
+// Classify sets the Components of each changelist to the component with the
+// most affected files or, if multi is set, to all of their components, so
+// that changelists spanning several components are reported under each.
+func (m *ComponentMap) Classify(cls []*Changelist, multi bool) {
+	if m == nil {
+		return
+	}
+	for _, cl := range cls {
+		components := m.Components(cl)
+		if !multi && len(components) > 1 {
+			components = components[:1]
+		}
+		cl.Components = components
+	}
+}
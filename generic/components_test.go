@@ -0,0 +1,152 @@
+package generic_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+const testComponents = `
+# Lines before any section apply to every repository.
+*.md              docs
+
+[golang/tools]
+internal/lsp/     gopls
+/gopls            gopls
+gopls/doc/        docs
+cmd/*             commands
+
+[golang/go]
+src/cmd/go        cmd/go
+src/cmd/go/internal/modload  modules
+test              tests
+`
+
+func TestParseComponentMap(t *testing.T) {
+	for _, tt := range []struct {
+		name, data string
+	}{
+		{"no component", "internal/lsp/"},
+		{"bad section", "[golang/tools"},
+		{"section without owner", "[tools]"},
+		{"bad pattern", "internal/[lsp gopls"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generic.ParseComponentMap([]byte(tt.data)); err == nil {
+				t.Errorf("ParseComponentMap(%q) succeeded, want an error", tt.data)
+			}
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	m, err := generic.ParseComponentMap([]byte(testComponents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		repo, file, want string
+	}{
+		{"golang/tools", "internal/lsp/source/completion/package.go", "gopls"},
+		{"golang/tools", "internal/lsp", "gopls"},
+		{"golang/tools", "internal/lspx/x.go", ""},
+		{"golang/tools", "gopls/main.go", "gopls"},
+		// The last matching line wins.
+		{"golang/tools", "gopls/doc/settings.md", "docs"},
+		// Even if an earlier line is more specific.
+		{"golang/tools", "internal/lsp/README.md", "gopls"},
+		// As in CODEOWNERS, wildcards do not match slashes.
+		{"golang/tools", "cmd/doc.go", "commands"},
+		{"golang/tools", "cmd/stringer/stringer.go", ""},
+		// Patterns without a slash match file names at any depth.
+		{"golang/go", "README.md", "docs"},
+		{"golang/go", "src/README.md", "docs"},
+		// Sections only apply to their repository.
+		{"golang/go", "internal/lsp/hover.go", ""},
+		{"golang/go", "src/cmd/go/main.go", "cmd/go"},
+		{"golang/go", "src/cmd/go/internal/modload/load.go", "modules"},
+		{"golang/go", "src/cmd/gofmt/gofmt.go", ""},
+		// Unlike in CODEOWNERS, other patterns without a slash are still
+		// relative to the root.
+		{"golang/go", "test/fixedbugs/issue1.go", "tests"},
+		{"golang/go", "src/cmd/go/test/x.go", "cmd/go"},
+	} {
+		if got := m.Component(tt.repo, tt.file); got != tt.want {
+			t.Errorf("Component(%q, %q) = %q, want %q", tt.repo, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestComponentMapClassify(t *testing.T) {
+	m, err := generic.ParseComponentMap([]byte(testComponents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCLs := func() []*generic.Changelist {
+		return []*generic.Changelist{
+			{Repo: "golang/tools", Link: "1", Subject: "gopls: docs", AffectedFiles: []string{
+				"internal/lsp/source/options.go",
+				"gopls/doc/settings.md",
+				"gopls/doc/features.md",
+			}},
+			// A tie between components is broken by name.
+			{Repo: "golang/tools", Link: "2", Subject: "all: update", AffectedFiles: []string{
+				"internal/lsp/hover.go",
+				"cmd/doc.go",
+				"go.mod",
+			}},
+			// Files without a component are categorized as before.
+			{Repo: "golang/tools", Link: "3", Subject: "go/packages: fix", AffectedFiles: []string{
+				"go/packages/packages.go",
+			}},
+		}
+	}
+	for _, tt := range []struct {
+		multi bool
+		want  [][]string
+	}{
+		{false, [][]string{{"docs"}, {"commands"}, {"go/packages"}}},
+		{true, [][]string{{"docs", "gopls"}, {"commands", "gopls"}, {"go/packages"}}},
+	} {
+		cls := newCLs()
+		m.Classify(cls, tt.multi)
+		var got [][]string
+		for _, cl := range cls {
+			got = append(got, cl.Categories())
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Classify(multi=%v) (-want +got):\n%s", tt.multi, diff)
+		}
+	}
+
+	// In multi-category mode, changelists are listed under each of their
+	// components, but only counted once in the totals.
+	cls := newCLs()
+	m.Classify(cls, true)
+	var got [][]string
+	for _, row := range generic.AuthoredChangelistsToCells(cls) {
+		var cells []string
+		for _, cell := range row.Cells[:3] {
+			cells = append(cells, cell.Text)
+		}
+		got = append(got, cells)
+	}
+	want := [][]string{
+		{"CL", "Description", ""},
+		{"2", "all: update", ""},
+		{"", "commands", "1"},
+		{"1", "gopls: docs", ""},
+		{"", "docs", "1"},
+		{"3", "go/packages: fix", ""},
+		{"", "go/packages", "1"},
+		{"1", "gopls: docs", ""},
+		{"2", "all: update", ""},
+		{"", "gopls", "2"},
+		{"Subtotal", "golang/tools", "3"},
+		{"Total", "", "3"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rows (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,126 @@
+package generic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stamblerre/sheets"
+)
+
+// Discussion is a GitHub Discussions thread that the user participated in.
+type Discussion struct {
+	Number   int
+	Link     string
+	Repo     string
+	Title    string
+	Category string
+	Author   string
+	Created  time.Time
+
+	// Started, Comments, and Answered describe the user's participation
+	// during the reporting period: whether they started the discussion, how
+	// many comments and replies they wrote, and whether one of their
+	// comments was marked as the answer.
+	Started  bool
+	Comments int
+	Answered bool
+}
+
+// Ref returns a short reference to the discussion, such as
+// "golang/go#1234".
+func (d *Discussion) Ref() string {
+	return fmt.Sprintf("%s#%d", d.Repo, d.Number)
+}
+
+// Actions describes what the user did in the discussion, such as
+// "started, commented".
+func (d *Discussion) Actions() string {
+	var actions []string
+	if d.Started {
+		actions = append(actions, "started")
+	}
+	if d.Comments > 0 {
+		actions = append(actions, "commented")
+	}
+	if d.Answered {
+		actions = append(actions, "answered")
+	}
+	return strings.Join(actions, ", ")
+}
+
+// DiscussionsToCells lays out the discussions that the user participated
+// in, by repository.
+func DiscussionsToCells(discussions []*Discussion) []*sheets.Row {
+	if len(discussions) == 0 {
+		return nil
+	}
+	repos := make(map[string][]*Discussion)
+	for _, d := range discussions {
+		repos[d.Repo] = append(repos[d.Repo], d)
+	}
+	var sortedRepos []string
+	for repo := range repos {
+		sortedRepos = append(sortedRepos, repo)
+	}
+	sort.Strings(sortedRepos)
+
+	cells := []*sheets.Row{{
+		Cells: []*sheets.Cell{
+			{Text: "Discussion"},
+			{Text: "Description"},
+			{Text: "Category"},
+			{Text: "Started"},
+			{Text: "Answered"},
+			{Text: "Number of Comments"},
+			{Text: "Total Discussions"},
+		},
+		BoldText: true,
+	}}
+	type total struct {
+		started, answered, comments, discussions int
+	}
+	asCells := func(t total) []string {
+		return []string{"", fmt.Sprint(t.started), fmt.Sprint(t.answered), fmt.Sprint(t.comments), fmt.Sprint(t.discussions)}
+	}
+	var grandTotal total
+	for _, repo := range sortedRepos {
+		discussions := repos[repo]
+		sort.Slice(discussions, func(i, j int) bool {
+			return discussions[i].Number < discussions[j].Number
+		})
+		var repoTotal total
+		for _, d := range discussions {
+			if d.Started {
+				repoTotal.started++
+			}
+			if d.Answered {
+				repoTotal.answered++
+			}
+			repoTotal.comments += d.Comments
+			repoTotal.discussions++
+			cells = append(cells, &sheets.Row{
+				Cells: []*sheets.Cell{
+					{Text: d.Link, Hyperlink: d.Link},
+					{Text: truncate(d.Title)},
+					{Text: d.Category},
+					{Text: strconv.FormatBool(d.Started)},
+					{Text: strconv.FormatBool(d.Answered)},
+					{Text: fmt.Sprint(d.Comments)},
+				},
+			})
+		}
+		// Only add the subtotal if there are multiple repos.
+		if len(repos) > 1 {
+			cells = append(cells, sheets.TotalRow(append([]string{"Subtotal", repo}, asCells(repoTotal)...)...))
+		}
+		grandTotal.started += repoTotal.started
+		grandTotal.answered += repoTotal.answered
+		grandTotal.comments += repoTotal.comments
+		grandTotal.discussions += repoTotal.discussions
+	}
+	cells = append(cells, sheets.TotalRow(append([]string{"Total", ""}, asCells(grandTotal)...)...))
+	return cells
+}
@@ -0,0 +1,80 @@
+package generic_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestDiscussionActions(t *testing.T) {
+	for _, tt := range []struct {
+		d    generic.Discussion
+		want string
+	}{
+		{generic.Discussion{Started: true}, "started"},
+		{generic.Discussion{Comments: 2}, "commented"},
+		{generic.Discussion{Started: true, Comments: 1, Answered: true}, "started, commented, answered"},
+		{generic.Discussion{}, ""},
+	} {
+		if got := tt.d.Actions(); got != tt.want {
+			t.Errorf("Actions(%+v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestDiscussionsToCells(t *testing.T) {
+	discussions := []*generic.Discussion{
+		{Number: 2, Link: "go/2", Repo: "golang/go", Title: "iterators", Category: "Ideas", Started: true, Comments: 3},
+		{Number: 1, Link: "go/1", Repo: "golang/go", Title: "modules", Category: "Q&A", Comments: 1, Answered: true},
+		{Number: 1, Link: "tools/1", Repo: "golang/tools", Title: "gopls", Category: "General", Comments: 2},
+	}
+	var got [][]string
+	for _, row := range generic.DiscussionsToCells(discussions) {
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Text)
+		}
+		got = append(got, cells)
+	}
+	want := [][]string{
+		{"Discussion", "Description", "Category", "Started", "Answered", "Number of Comments", "Total Discussions"},
+		{"go/1", "modules", "Q&A", "false", "true", "1"},
+		{"go/2", "iterators", "Ideas", "true", "false", "3"},
+		{"Subtotal", "golang/go", "", "1", "1", "4", "2"},
+		{"tools/1", "gopls", "General", "false", "false", "2"},
+		{"Subtotal", "golang/tools", "", "0", "0", "2", "1"},
+		{"Total", "", "", "1", "1", "6", "3"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rows (-want +got):\n%s", diff)
+	}
+	if rows := generic.DiscussionsToCells(nil); rows != nil {
+		t.Errorf("got %d rows for no discussions, want none", len(rows))
+	}
+}
+
+func TestMentionsUser(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want bool
+	}{
+		{"@alice", true},
+		{"cc @alice, @bob", true},
+		{"Thanks, @alice.", true},
+		{"(@alice)", true},
+		{"alice", false},
+		{"alice@example.com", false},
+		{"@alicex", false},
+		{"@alice-bot", false},
+		{"@Alice", true},
+		{"@alicex and then @alice", true},
+	} {
+		if got := generic.MentionsUser(tt.text, "alice"); got != tt.want {
+			t.Errorf("MentionsUser(%q, %q) = %v, want %v", tt.text, "alice", got, tt.want)
+		}
+	}
+	if generic.MentionsUser("@ hello", "") {
+		t.Errorf("MentionsUser matched an empty username")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	rsheets "github.com/stamblerre/sheets"
@@ -21,12 +22,41 @@ type Issue struct {
 	Labels                 []string
 	Transferred            bool
 	Milestone              string
+
+	// ClosedByCL is the merged CL that closed the issue, if any. ClosedBy is
+	// then the user, if they wrote the CL, or else whoever closed the issue,
+	// such as gopherbot.
+	ClosedByCL string
+
+	// Triage are the user's triage actions on the issue, such as labeling
+	// or assigning it.
+	Triage []*TriageAction
+
+	// Reactions is the number of reactions, such as +1, that the user gave
+	// to the issue and its comments.
+	Reactions int
+	// Mentions is the number of times that other people @-mentioned the
+	// user on the issue.
+	Mentions int
+
+	// Class is the category assigned to the issue by Rules, if any.
+	Class string
 }
 
+// Category returns the issue's Class, if it has one, or else the prefix of
+// its title, such as "x/tools/gopls" in "x/tools/gopls: crash on hover".
 func (issue Issue) Category() string {
+	if issue.Class != "" {
+		return issue.Class
+	}
 	return extractCategory(issue.Title)
 }
 
+// Ref returns a short reference to the issue, such as "golang/go#1234".
+func (issue Issue) Ref() string {
+	return fmt.Sprintf("%s#%d", issue.Repo, issue.Number)
+}
+
 func (issue Issue) OpenedByUser(username string) bool {
 	return issue.OpenedBy == username
 }
@@ -39,8 +69,36 @@ func (issue Issue) Closed() bool {
 	return !issue.DateClosed.IsZero()
 }
 
+// MentionsUser reports whether text @-mentions the user, as in "cc @alice".
+// Like GitHub usernames, mentions are case-insensitive.
+func MentionsUser(text, username string) bool {
+	if username == "" {
+		return false
+	}
+	text = strings.ToLower(text)
+	mention := "@" + strings.ToLower(username)
+	for {
+		i := strings.Index(text, mention)
+		if i < 0 {
+			return false
+		}
+		// The mention must not be part of an email address or a longer
+		// username.
+		before, after := i-1, i+len(mention)
+		if (before < 0 || !isUsernameByte(text[before])) && (after >= len(text) || !isUsernameByte(text[after])) {
+			return true
+		}
+		text = text[after:]
+	}
+}
+
+func isUsernameByte(b byte) bool {
+	return b == '-' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
 type issueTotal struct {
 	issues, comments, opened, closed int
+	reactions, mentions              int
 }
 
 func (t1 *issueTotal) asCells() []string {
@@ -48,6 +106,8 @@ func (t1 *issueTotal) asCells() []string {
 		fmt.Sprint(t1.opened),
 		fmt.Sprint(t1.closed),
 		fmt.Sprint(t1.comments),
+		fmt.Sprint(t1.reactions),
+		fmt.Sprint(t1.mentions),
 		fmt.Sprint(t1.issues),
 	}
 }
@@ -57,6 +117,8 @@ func (t1 *issueTotal) add(t2 *issueTotal) {
 	t1.comments += t2.comments
 	t1.opened += t2.opened
 	t1.closed += t2.closed
+	t1.reactions += t2.reactions
+	t1.mentions += t2.mentions
 }
 
 func IssuesToCells(username string, issues []*Issue) []*rsheets.Row {
@@ -80,6 +142,8 @@ func IssuesToCells(username string, issues []*Issue) []*rsheets.Row {
 		{Text: "Opened"},
 		{Text: "Closed"},
 		{Text: "Number of Comments"},
+		{Text: "Reactions"},
+		{Text: "Mentions"},
 		{Text: "Total Issues"},
 	}})
 	grandTotal := &issueTotal{}
@@ -113,6 +177,8 @@ func IssuesToCells(username string, issues []*Issue) []*rsheets.Row {
 					categoryTotal.closed++
 				}
 				categoryTotal.comments += issue.Comments
+				categoryTotal.reactions += issue.Reactions
+				categoryTotal.mentions += issue.Mentions
 				cells = append(cells, &rsheets.Row{
 					Cells: []*rsheets.Cell{
 						{Text: issue.Link, Hyperlink: issue.Link},
@@ -120,6 +186,8 @@ func IssuesToCells(username string, issues []*Issue) []*rsheets.Row {
 						{Text: strconv.FormatBool(opened)},
 						{Text: strconv.FormatBool(closed)},
 						{Text: strconv.FormatInt(int64(issue.Comments), 10)},
+						{Text: strconv.FormatInt(int64(issue.Reactions), 10)},
+						{Text: strconv.FormatInt(int64(issue.Mentions), 10)},
 					}})
 			}
 			if len(sortedCategories) > 1 {
@@ -0,0 +1,197 @@
+package generic
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/stamblerre/sheets"
+)
+
+// Latency summarizes how long a group of changelists waited for review and
+// took to merge.
+type Latency struct {
+	Changelists int
+	Reviewed    int
+	Merged      int
+
+	MedianTimeToFirstReview time.Duration
+	P90TimeToFirstReview    time.Duration
+	MedianTimeToMerge       time.Duration
+	P90TimeToMerge          time.Duration
+}
+
+// MarshalJSON writes durations in their human-readable form, such as
+// "26h30m0s", rather than in nanoseconds.
+func (l *Latency) MarshalJSON() ([]byte, error) {
+	format := func(d time.Duration, n int) string {
+		if n == 0 {
+			return ""
+		}
+		return d.String()
+	}
+	return json.Marshal(struct {
+		Changelists             int    `json:"changelists"`
+		Reviewed                int    `json:"reviewed"`
+		Merged                  int    `json:"merged"`
+		MedianTimeToFirstReview string `json:"median_time_to_first_review,omitempty"`
+		P90TimeToFirstReview    string `json:"p90_time_to_first_review,omitempty"`
+		MedianTimeToMerge       string `json:"median_time_to_merge,omitempty"`
+		P90TimeToMerge          string `json:"p90_time_to_merge,omitempty"`
+	}{
+		Changelists:             l.Changelists,
+		Reviewed:                l.Reviewed,
+		Merged:                  l.Merged,
+		MedianTimeToFirstReview: format(l.MedianTimeToFirstReview, l.Reviewed),
+		P90TimeToFirstReview:    format(l.P90TimeToFirstReview, l.Reviewed),
+		MedianTimeToMerge:       format(l.MedianTimeToMerge, l.Merged),
+		P90TimeToMerge:          format(l.P90TimeToMerge, l.Merged),
+	})
+}
+
+// Metrics reports review and merge latency for a set of changelists, broken
+// down by author and by repository.
+type Metrics struct {
+	ByAuthor map[string]*Latency `json:"by_author"`
+	ByRepo   map[string]*Latency `json:"by_repo"`
+	Total    *Latency            `json:"total"`
+}
+
+// ComputeMetrics computes latency metrics for the given changelists.
+// Changelists with the same link are only counted once.
+func ComputeMetrics(cls []*Changelist) *Metrics {
+	seen := make(map[string]bool)
+	var all []*Changelist
+	authors := make(map[string][]*Changelist)
+	repos := make(map[string][]*Changelist)
+	for _, cl := range cls {
+		if seen[cl.Link] {
+			continue
+		}
+		seen[cl.Link] = true
+		all = append(all, cl)
+		authors[cl.Author] = append(authors[cl.Author], cl)
+		repos[cl.Repo] = append(repos[cl.Repo], cl)
+	}
+	m := &Metrics{
+		ByAuthor: make(map[string]*Latency),
+		ByRepo:   make(map[string]*Latency),
+		Total:    computeLatency(all),
+	}
+	for author, cls := range authors {
+		m.ByAuthor[author] = computeLatency(cls)
+	}
+	for repo, cls := range repos {
+		m.ByRepo[repo] = computeLatency(cls)
+	}
+	return m
+}
+
+func computeLatency(cls []*Changelist) *Latency {
+	var reviews, merges []time.Duration
+	for _, cl := range cls {
+		if d, ok := cl.TimeToFirstReview(); ok {
+			reviews = append(reviews, d)
+		}
+		if d, ok := cl.TimeToMerge(); ok {
+			merges = append(merges, d)
+		}
+	}
+	return &Latency{
+		Changelists:             len(cls),
+		Reviewed:                len(reviews),
+		Merged:                  len(merges),
+		MedianTimeToFirstReview: percentile(reviews, 50),
+		P90TimeToFirstReview:    percentile(reviews, 90),
+		MedianTimeToMerge:       percentile(merges, 50),
+		P90TimeToMerge:          percentile(merges, 90),
+	}
+}
+
+// percentile returns the pth percentile of durations, using the
+// nearest-rank method. It returns 0 for an empty slice.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func MetricsToCells(m *Metrics) []*sheets.Row {
+	if m == nil || m.Total.Changelists == 0 {
+		return nil
+	}
+	rows := []*sheets.Row{{
+		Cells: []*sheets.Cell{
+			{Text: ""},
+			{Text: "CLs"},
+			{Text: "Reviewed"},
+			{Text: "Median Time to First Review"},
+			{Text: "P90 Time to First Review"},
+			{Text: "Merged"},
+			{Text: "Median Time to Merge"},
+			{Text: "P90 Time to Merge"},
+		},
+		BoldText: true,
+	}}
+	for _, section := range []struct {
+		title     string
+		latencies map[string]*Latency
+	}{
+		{"Repository", m.ByRepo},
+		{"Author", m.ByAuthor},
+	} {
+		rows = append(rows, &sheets.Row{
+			Cells:    []*sheets.Cell{{Text: section.title}},
+			BoldText: true,
+		})
+		var names []string
+		for name := range section.latencies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			var cells []*sheets.Cell
+			for _, text := range append([]string{name}, section.latencies[name].asCells()...) {
+				cells = append(cells, &sheets.Cell{Text: text})
+			}
+			rows = append(rows, &sheets.Row{Cells: cells})
+		}
+	}
+	rows = append(rows, sheets.TotalRow(append([]string{"Total"}, m.Total.asCells()...)...))
+	return rows
+}
+
+func (l *Latency) asCells() []string {
+	cells := []string{fmt.Sprint(l.Changelists), fmt.Sprint(l.Reviewed)}
+	if l.Reviewed > 0 {
+		cells = append(cells, formatDuration(l.MedianTimeToFirstReview), formatDuration(l.P90TimeToFirstReview))
+	} else {
+		cells = append(cells, "", "")
+	}
+	cells = append(cells, fmt.Sprint(l.Merged))
+	if l.Merged > 0 {
+		cells = append(cells, formatDuration(l.MedianTimeToMerge), formatDuration(l.P90TimeToMerge))
+	} else {
+		cells = append(cells, "", "")
+	}
+	return cells
+}
+
+// formatDuration formats d in days or hours, whichever is more readable.
+func formatDuration(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%.1f days", d.Hours()/24)
+	}
+	return fmt.Sprintf("%.1f hours", d.Hours())
+}
@@ -0,0 +1,121 @@
+package generic_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestComputeMetrics(t *testing.T) {
+	created := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cl := func(link, author, repo string, review, merge time.Duration) *generic.Changelist {
+		cl := &generic.Changelist{
+			Link:      link,
+			Author:    author,
+			Repo:      repo,
+			Status:    generic.New,
+			CreatedAt: created,
+		}
+		if review != 0 {
+			cl.FirstReviewAt = created.Add(review)
+		}
+		if merge != 0 {
+			cl.Status = generic.Merged
+			cl.MergedAt = created.Add(merge)
+		}
+		return cl
+	}
+	cls := []*generic.Changelist{
+		cl("a/1", "alice", "tools", 1*time.Hour, 10*time.Hour),
+		cl("a/2", "alice", "tools", 2*time.Hour, 20*time.Hour),
+		cl("a/3", "alice", "go", 3*time.Hour, 30*time.Hour),
+		cl("a/4", "alice", "go", 4*time.Hour, 0),
+		cl("b/1", "bob", "tools", 0, 0),
+		// Duplicates are ignored.
+		cl("a/1", "alice", "tools", 1*time.Hour, 10*time.Hour),
+	}
+	got := generic.ComputeMetrics(cls)
+	want := &generic.Metrics{
+		ByAuthor: map[string]*generic.Latency{
+			"alice": {
+				Changelists:             4,
+				Reviewed:                4,
+				Merged:                  3,
+				MedianTimeToFirstReview: 2 * time.Hour,
+				P90TimeToFirstReview:    4 * time.Hour,
+				MedianTimeToMerge:       20 * time.Hour,
+				P90TimeToMerge:          30 * time.Hour,
+			},
+			"bob": {
+				Changelists: 1,
+			},
+		},
+		ByRepo: map[string]*generic.Latency{
+			"go": {
+				Changelists:             2,
+				Reviewed:                2,
+				Merged:                  1,
+				MedianTimeToFirstReview: 3 * time.Hour,
+				P90TimeToFirstReview:    4 * time.Hour,
+				MedianTimeToMerge:       30 * time.Hour,
+				P90TimeToMerge:          30 * time.Hour,
+			},
+			"tools": {
+				Changelists:             3,
+				Reviewed:                2,
+				Merged:                  2,
+				MedianTimeToFirstReview: 1 * time.Hour,
+				P90TimeToFirstReview:    2 * time.Hour,
+				MedianTimeToMerge:       10 * time.Hour,
+				P90TimeToMerge:          20 * time.Hour,
+			},
+		},
+		Total: &generic.Latency{
+			Changelists:             5,
+			Reviewed:                4,
+			Merged:                  3,
+			MedianTimeToFirstReview: 2 * time.Hour,
+			P90TimeToFirstReview:    4 * time.Hour,
+			MedianTimeToMerge:       20 * time.Hour,
+			P90TimeToMerge:          30 * time.Hour,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected metrics: %s", diff)
+	}
+}
+
+func TestLatencyJSON(t *testing.T) {
+	for _, tt := range []struct {
+		latency *generic.Latency
+		want    string
+	}{
+		{
+			latency: &generic.Latency{Changelists: 1},
+			want:    `{"changelists":1,"reviewed":0,"merged":0}`,
+		},
+		{
+			latency: &generic.Latency{
+				Changelists:             2,
+				Reviewed:                1,
+				Merged:                  1,
+				MedianTimeToFirstReview: 90 * time.Minute,
+				P90TimeToFirstReview:    90 * time.Minute,
+				MedianTimeToMerge:       26 * time.Hour,
+				P90TimeToMerge:          26 * time.Hour,
+			},
+			want: `{"changelists":2,"reviewed":1,"merged":1,"median_time_to_first_review":"1h30m0s","p90_time_to_first_review":"1h30m0s","median_time_to_merge":"26h0m0s","p90_time_to_merge":"26h0m0s"}`,
+		},
+	} {
+		got, err := json.Marshal(tt.latency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+}
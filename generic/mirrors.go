@@ -0,0 +1,88 @@
+package generic
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// pullRequestFooterRx matches the footer that gerritbot adds to the
+	// commit message of a CL it imports from a GitHub PR.
+	pullRequestFooterRx = regexp.MustCompile(`(?m)^GitHub-Pull-Request: *(\S+/\S+#\d+)\s*$`)
+
+	// gerritLinkRx matches a link to a Gerrit CL, such as one in a PR's body.
+	gerritLinkRx = regexp.MustCompile(`go-review\.googlesource\.com/c/([^\s+]+)/\+/(\d+)`)
+
+	// pullRequestLinkRx matches a link to a GitHub PR, such as a CL's Mirror.
+	pullRequestLinkRx = regexp.MustCompile(`github\.com/([^/\s]+/[^/\s]+)/pull/(\d+)$`)
+)
+
+// PullRequest returns the GitHub PR that the changelist was imported from,
+// such as "golang/tools#123", based on its GitHub-Pull-Request footer. It
+// returns "" if the changelist was not imported from GitHub.
+func (cl *Changelist) PullRequest() string {
+	if m := pullRequestFooterRx.FindStringSubmatch(cl.Message); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// MirrorRef returns a short reference to the changelist's Mirror, such as
+// "golang/tools#123" for a GitHub PR, or else the Mirror itself.
+func (cl *Changelist) MirrorRef() string {
+	if m := pullRequestLinkRx.FindStringSubmatch(cl.Mirror); m != nil {
+		return m[1] + "#" + m[2]
+	}
+	return cl.Mirror
+}
+
+// gerritLinks returns the Gerrit CLs linked to from the changelist's
+// message, in the same form as Changelist.Link.
+func (cl *Changelist) gerritLinks() []string {
+	var links []string
+	for _, m := range gerritLinkRx.FindAllStringSubmatch(cl.Message, -1) {
+		links = append(links, "go-review.googlesource.com/c/"+m[1]+"/+/"+m[2])
+	}
+	return links
+}
+
+// DedupChangelists links the Gerrit CLs in gerrit with the GitHub PRs in
+// github that are the same change, such as a PR that gerritbot imported into
+// Gerrit. A CL and a PR are the same change if the CL's GitHub-Pull-Request
+// footer names the PR, or if the PR's body links to the CL.
+//
+// The work is reported once, as the Gerrit CL, since that is where it is
+// reviewed and merged. The CL's Mirror is set to the PR's link, and
+// DedupChangelists returns the PRs that are not linked to any CL. PRs in the
+// golang organization are only mirrors of their CLs, so those that are not
+// linked to one are dropped too, such as those of abandoned CLs, or all of
+// them if Gerrit data was not collected.
+func DedupChangelists(gerrit, github []*Changelist) []*Changelist {
+	byPR := make(map[string]*Changelist)
+	byLink := make(map[string]*Changelist)
+	for _, cl := range gerrit {
+		if pr := cl.PullRequest(); pr != "" {
+			byPR[pr] = cl
+		}
+		byLink[cl.Link] = cl
+	}
+	var unlinked []*Changelist
+	for _, pr := range github {
+		cl, ok := byPR[pr.Ref()]
+		if !ok {
+			for _, link := range pr.gerritLinks() {
+				if cl, ok = byLink[link]; ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			if !strings.HasPrefix(pr.Repo, "golang/") {
+				unlinked = append(unlinked, pr)
+			}
+			continue
+		}
+		cl.Mirror = pr.Link
+	}
+	return unlinked
+}
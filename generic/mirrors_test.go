@@ -0,0 +1,64 @@
+package generic_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestDedupChangelists(t *testing.T) {
+	imported := &generic.Changelist{
+		Number:  1005,
+		Link:    "go-review.googlesource.com/c/tools/+/1005",
+		Repo:    "tools",
+		Message: "gopls: fix typo\n\nChange-Id: I0000000000000000000000000000000000001005\nGitHub-Pull-Request: golang/tools#7\n",
+	}
+	linked := &generic.Changelist{
+		Number:  1006,
+		Link:    "go-review.googlesource.com/c/go/+/1006",
+		Repo:    "go",
+		Message: "cmd/go: fix build\n",
+	}
+	other := &generic.Changelist{
+		Number:  1007,
+		Link:    "go-review.googlesource.com/c/tools/+/1007",
+		Repo:    "tools",
+		Message: "gopls: unrelated\n\nGitHub-Pull-Request: golang/tools#70\n",
+	}
+	prs := []*generic.Changelist{
+		{Number: 7, Link: "https://github.com/golang/tools/pull/7", Repo: "golang/tools"},
+		{Number: 8, Link: "https://github.com/golang/go/pull/8", Repo: "golang/go", Message: "See https://go-review.googlesource.com/c/go/+/1006 for review."},
+		{Number: 9, Link: "https://github.com/stamblerre/sheets/pull/9", Repo: "stamblerre/sheets", Message: "Like go-review.googlesource.com/c/go/+/1234."},
+		// golang PRs without a CL, such as those of abandoned CLs, are
+		// dropped, since they are only mirrors.
+		{Number: 10, Link: "https://github.com/golang/tools/pull/10", Repo: "golang/tools"},
+	}
+	got := generic.DedupChangelists([]*generic.Changelist{imported, linked, other}, prs)
+	if diff := cmp.Diff([]*generic.Changelist{prs[2]}, got); diff != "" {
+		t.Errorf("unexpected unlinked PRs: %s", diff)
+	}
+	for _, tt := range []struct {
+		cl   *generic.Changelist
+		want string
+	}{
+		{imported, "https://github.com/golang/tools/pull/7"},
+		{linked, "https://github.com/golang/go/pull/8"},
+		{other, ""},
+	} {
+		if tt.cl.Mirror != tt.want {
+			t.Errorf("CL %d: got mirror %q, want %q", tt.cl.Number, tt.cl.Mirror, tt.want)
+		}
+	}
+}
+
+func TestDedupChangelistsWithoutGerrit(t *testing.T) {
+	prs := []*generic.Changelist{
+		{Number: 7, Link: "https://github.com/golang/tools/pull/7", Repo: "golang/tools"},
+		{Number: 9, Link: "https://github.com/stamblerre/sheets/pull/9", Repo: "stamblerre/sheets"},
+	}
+	got := generic.DedupChangelists(nil, prs)
+	if diff := cmp.Diff([]*generic.Changelist{prs[1]}, got); diff != "" {
+		t.Errorf("unexpected unlinked PRs: %s", diff)
+	}
+}
@@ -0,0 +1,482 @@
+package generic
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stamblerre/sheets"
+)
+
+// Ownership records who owns the files of each repository, as read from the
+// CODEOWNERS and OWNERS files of local checkouts.
+//
+// A CODEOWNERS file, in .github/, docs/, or the root of the checkout, has a
+// pattern and its owners on each line, and the last line that matches a file
+// decides its owners. A line without owners leaves the files it matches
+// unowned. Go-style OWNERS files list one owner per line, and apply to the
+// files in their directory and its subdirectories, together with the owners
+// of the parent directories unless the file says "set noparent". Files that a
+// CODEOWNERS line matches are not looked up in OWNERS files.
+type Ownership struct {
+	repos map[string]*repoOwnership
+}
+
+type repoOwnership struct {
+	codeowners []*codeownersLine
+	owners     map[string]*OwnedArea // by directory, "." for the root
+}
+
+type codeownersLine struct {
+	pattern codeownersPattern
+	area    *OwnedArea // nil if the files are unowned
+}
+
+// codeownersPattern is a CODEOWNERS pattern for the files in a repository. A
+// pattern with a slash, such as "/gopls" or "internal/lsp/", is a path
+// relative to the root of the repository, and one without, such as
+// "testdata", matches at any depth. It matches the file at that path, or any
+// file in the directory at that path. A pattern with wildcards, such as
+// "src/cmd/*", is matched as a whole with path.Match, so that wildcards do not
+// match slashes, and one without a slash, such as "*.md", is matched against
+// the file's name.
+type codeownersPattern struct {
+	pattern  string // without leading and trailing slashes
+	anchored bool
+}
+
+func parseCodeownersPattern(s string) (codeownersPattern, error) {
+	p := codeownersPattern{
+		pattern:  strings.Trim(s, "/"),
+		anchored: strings.Contains(strings.TrimSuffix(s, "/"), "/"),
+	}
+	if _, err := path.Match(p.pattern, ""); err != nil {
+		return codeownersPattern{}, fmt.Errorf("invalid pattern %q: %v", s, err)
+	}
+	return p, nil
+}
+
+func (p codeownersPattern) matches(file string) bool {
+	switch {
+	case p.pattern == "":
+		// A pattern of "/" matches the whole repository.
+		return true
+	case strings.ContainsAny(p.pattern, "*?[") && p.anchored:
+		ok, _ := path.Match(p.pattern, file)
+		return ok
+	case strings.ContainsAny(p.pattern, "*?["):
+		ok, _ := path.Match(p.pattern, path.Base(file))
+		return ok
+	case p.anchored:
+		return file == p.pattern || strings.HasPrefix(file, p.pattern+"/")
+	default:
+		return strings.Contains("/"+file+"/", "/"+p.pattern+"/")
+	}
+}
+
+// OwnedArea is a part of a repository with its own owners: the files matched
+// by a CODEOWNERS pattern, or a directory with an OWNERS file.
+type OwnedArea struct {
+	Repo   string   `json:"repo"`
+	Path   string   `json:"path"`
+	Owners []string `json:"owners"`
+}
+
+// OwnedBy reports whether any of the identities, such as the user's GitHub
+// username and emails, is an owner of the area. Owners are compared without
+// case or a leading "@", and an owner of "*" includes everyone.
+func (a *OwnedArea) OwnedBy(identities []string) bool {
+	for _, owner := range a.Owners {
+		if owner == "*" {
+			return true
+		}
+		for _, id := range identities {
+			if id != "" && strings.EqualFold(strings.TrimPrefix(owner, "@"), strings.TrimPrefix(id, "@")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseCheckouts parses a comma-separated list of local checkouts, each
+// either "repo=path" or a path whose base name is the repository, such as
+// "~/go/src/golang.org/x/tools" for the tools repository.
+func ParseCheckouts(s string) (map[string]string, error) {
+	checkouts := make(map[string]string)
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		repo, dir := filepath.Base(c), c
+		if i := strings.Index(c, "="); i >= 0 {
+			repo, dir = c[:i], c[i+1:]
+		}
+		if repo == "" || dir == "" {
+			return nil, fmt.Errorf("invalid checkout %q, want repo=path", c)
+		}
+		if _, ok := checkouts[repo]; ok {
+			return nil, fmt.Errorf("more than one checkout of %s", repo)
+		}
+		checkouts[repo] = dir
+	}
+	return checkouts, nil
+}
+
+// LoadOwnership reads the CODEOWNERS and OWNERS files of the given local
+// checkouts, by repository.
+func LoadOwnership(checkouts map[string]string) (*Ownership, error) {
+	o := &Ownership{repos: make(map[string]*repoOwnership)}
+	for repo, dir := range checkouts {
+		ro, err := loadRepoOwnership(repo, dir)
+		if err != nil {
+			return nil, err
+		}
+		o.repos[repo] = ro
+	}
+	return o, nil
+}
+
+func loadRepoOwnership(repo, dir string) (*repoOwnership, error) {
+	ro := &repoOwnership{owners: make(map[string]*OwnedArea)}
+	// As on GitHub, only the first CODEOWNERS file found is used.
+	for _, name := range []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		data, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ro.codeowners, err = parseCodeowners(repo, data); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		break
+	}
+	owners := make(map[string][]string)
+	noparent := make(map[string]bool)
+	err := filepath.Walk(dir, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != "OWNERS" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filepath.Dir(filename))
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		d := filepath.ToSlash(rel)
+		owners[d], noparent[d] = parseOwners(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Each directory's owners include those of its parents, up to the
+	// first OWNERS file that says "set noparent".
+	for d := range owners {
+		area := &OwnedArea{Repo: repo, Path: d + "/"}
+		if d == "." {
+			area.Path = "/"
+		}
+		seen := make(map[string]bool)
+		for p := d; ; p = path.Dir(p) {
+			for _, owner := range owners[p] {
+				if !seen[owner] {
+					seen[owner] = true
+					area.Owners = append(area.Owners, owner)
+				}
+			}
+			if noparent[p] || p == "." {
+				break
+			}
+		}
+		ro.owners[d] = area
+	}
+	return ro, nil
+}
+
+func parseCodeowners(repo string, data []byte) ([]*codeownersLine, error) {
+	var lines []*codeownersLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		// Skip blank lines and GitLab's section headings, such as
+		// "[Documentation]" or "^[Optional]".
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+		pattern, err := parseCodeownersPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		l := &codeownersLine{pattern: pattern}
+		if len(fields) > 1 {
+			l.area = &OwnedArea{Repo: repo, Path: fields[0], Owners: fields[1:]}
+		}
+		lines = append(lines, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseOwners parses a Go-style OWNERS file, returning its owners and whether
+// it says "set noparent". Other directives, such as "per-file" and "file:",
+// are ignored.
+func parseOwners(data []byte) (owners []string, noparent bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case len(fields) == 2 && fields[0] == "set" && fields[1] == "noparent":
+			noparent = true
+		case len(fields) == 1 && !strings.Contains(fields[0], ":"):
+			owners = append(owners, fields[0])
+		}
+	}
+	return owners, noparent
+}
+
+// Known reports whether the ownership of the repository's files is known,
+// that is, whether a checkout of it was loaded. A checkout of "golang/tools"
+// is also that of the Gerrit project "tools".
+func (o *Ownership) Known(repo string) bool {
+	return o.repo(repo) != nil
+}
+
+func (o *Ownership) repo(repo string) *repoOwnership {
+	if o == nil {
+		return nil
+	}
+	if ro := o.repos[repo]; ro != nil {
+		return ro
+	}
+	for name, ro := range o.repos {
+		if sameRepo(name, repo) {
+			return ro
+		}
+	}
+	return nil
+}
+
+// Area returns the owned area that a file of the repository belongs to, or
+// nil if the file is unowned or the repository is not known.
+func (o *Ownership) Area(repo, file string) *OwnedArea {
+	ro := o.repo(repo)
+	if ro == nil {
+		return nil
+	}
+	for i := len(ro.codeowners) - 1; i >= 0; i-- {
+		if l := ro.codeowners[i]; l.pattern.matches(file) {
+			return l.area
+		}
+	}
+	for d := path.Dir(file); ; d = path.Dir(d) {
+		if area := ro.owners[d]; area != nil {
+			return area
+		}
+		if d == "." {
+			return nil
+		}
+	}
+}
+
+// OwnershipSummary reports how much of a user's authored and reviewed work
+// landed in areas that they own, in areas owned by others, and in unowned
+// files.
+type OwnershipSummary struct {
+	// Areas are the areas that the changelists affected, ordered by
+	// repository and path. The unowned files of each repository are in an
+	// area without a path or owners.
+	Areas []*AreaWork `json:"areas"`
+
+	// A changelist that affects several areas counts toward each of them,
+	// so it may count toward more than one of Yours, Others, and Unowned,
+	// but only once toward each and toward Total.
+	Yours   *WorkCount `json:"yours"`
+	Others  *WorkCount `json:"others"`
+	Unowned *WorkCount `json:"unowned"`
+	Total   *WorkCount `json:"total"`
+}
+
+// AreaWork counts the changelists that affected an owned area.
+type AreaWork struct {
+	*OwnedArea
+	Yours bool `json:"yours"`
+	WorkCount
+}
+
+// WorkCount counts authored and reviewed changelists, and the files they
+// affected.
+type WorkCount struct {
+	AuthoredCLs   int `json:"authored_cls"`
+	AuthoredFiles int `json:"authored_files"`
+	ReviewedCLs   int `json:"reviewed_cls"`
+	ReviewedFiles int `json:"reviewed_files"`
+}
+
+// ComputeOwnership attributes the files that the authored and reviewed
+// changelists affected to their owned areas. Identities, such as the user's
+// GitHub username and emails, decide which areas are the user's. Changelists
+// in repositories whose ownership is not known are left out, and changelists
+// with the same link are only counted once.
+func ComputeOwnership(o *Ownership, identities []string, authored, reviewed []*Changelist) *OwnershipSummary {
+	s := &OwnershipSummary{
+		Yours:   &WorkCount{},
+		Others:  &WorkCount{},
+		Unowned: &WorkCount{},
+		Total:   &WorkCount{},
+	}
+	areas := make(map[*OwnedArea]*AreaWork)
+	unowned := make(map[string]*OwnedArea) // by repository
+	for _, group := range []struct {
+		cls   []*Changelist
+		count func(*WorkCount, int)
+	}{
+		{authored, func(c *WorkCount, files int) { c.AuthoredCLs++; c.AuthoredFiles += files }},
+		{reviewed, func(c *WorkCount, files int) { c.ReviewedCLs++; c.ReviewedFiles += files }},
+	} {
+		seen := make(map[string]bool)
+		for _, cl := range group.cls {
+			if seen[cl.Link] || !o.Known(cl.Repo) || len(cl.AffectedFiles) == 0 {
+				continue
+			}
+			seen[cl.Link] = true
+			files := make(map[*AreaWork]int)
+			for _, file := range cl.AffectedFiles {
+				area := o.Area(cl.Repo, file)
+				if area == nil {
+					if unowned[cl.Repo] == nil {
+						unowned[cl.Repo] = &OwnedArea{Repo: cl.Repo}
+					}
+					area = unowned[cl.Repo]
+				}
+				w := areas[area]
+				if w == nil {
+					w = &AreaWork{OwnedArea: area, Yours: area.OwnedBy(identities)}
+					areas[area] = w
+					s.Areas = append(s.Areas, w)
+				}
+				files[w]++
+			}
+			classes := make(map[*WorkCount]int)
+			for w, n := range files {
+				group.count(&w.WorkCount, n)
+				classes[s.class(w)] += n
+			}
+			for c, n := range classes {
+				group.count(c, n)
+			}
+			group.count(s.Total, len(cl.AffectedFiles))
+		}
+	}
+	sort.Slice(s.Areas, func(i, j int) bool {
+		if s.Areas[i].Repo != s.Areas[j].Repo {
+			return s.Areas[i].Repo < s.Areas[j].Repo
+		}
+		return s.Areas[i].Path < s.Areas[j].Path
+	})
+	return s
+}
+
+// class returns the count of the class of areas that w belongs to.
+func (s *OwnershipSummary) class(w *AreaWork) *WorkCount {
+	switch {
+	case len(w.Owners) == 0:
+		return s.Unowned
+	case w.Yours:
+		return s.Yours
+	default:
+		return s.Others
+	}
+}
+
+// OwnershipToCells lays out the areas that the user's changelists affected,
+// grouped into the user's areas, others' areas, and unowned files.
+func OwnershipToCells(s *OwnershipSummary) []*sheets.Row {
+	if s == nil || len(s.Areas) == 0 {
+		return nil
+	}
+	rows := []*sheets.Row{{
+		Cells: []*sheets.Cell{
+			{Text: "Repository"},
+			{Text: "Area"},
+			{Text: "Owners"},
+			{Text: "Authored CLs"},
+			{Text: "Authored Files"},
+			{Text: "Reviewed CLs"},
+			{Text: "Reviewed Files"},
+		},
+		BoldText: true,
+	}}
+	for _, section := range []struct {
+		title string
+		count *WorkCount
+	}{
+		{"Your areas", s.Yours},
+		{"Others' areas", s.Others},
+		{"Unowned", s.Unowned},
+	} {
+		var areas []*AreaWork
+		for _, w := range s.Areas {
+			if s.class(w) == section.count {
+				areas = append(areas, w)
+			}
+		}
+		if len(areas) == 0 {
+			continue
+		}
+		rows = append(rows, &sheets.Row{
+			Cells:    []*sheets.Cell{{Text: section.title}},
+			BoldText: true,
+		})
+		for _, w := range areas {
+			p := w.Path
+			if p == "" {
+				p = "(unowned)"
+			}
+			var cells []*sheets.Cell
+			for _, text := range append([]string{w.Repo, p, strings.Join(w.Owners, ", ")}, w.asCells()...) {
+				cells = append(cells, &sheets.Cell{Text: text})
+			}
+			rows = append(rows, &sheets.Row{Cells: cells})
+		}
+		rows = append(rows, sheets.TotalRow(append([]string{"Subtotal", section.title, ""}, section.count.asCells()...)...))
+	}
+	rows = append(rows, sheets.TotalRow(append([]string{"Total", "", ""}, s.Total.asCells()...)...))
+	return rows
+}
+
+func (c *WorkCount) asCells() []string {
+	return []string{
+		fmt.Sprint(c.AuthoredCLs),
+		fmt.Sprint(c.AuthoredFiles),
+		fmt.Sprint(c.ReviewedCLs),
+		fmt.Sprint(c.ReviewedFiles),
+	}
+}
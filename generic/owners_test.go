@@ -0,0 +1,209 @@
+package generic_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+// writeCheckout writes the given files, by slash-separated path, to a
+// temporary directory.
+func writeCheckout(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testOwnership(t *testing.T) *generic.Ownership {
+	t.Helper()
+	o, err := generic.LoadOwnership(map[string]string{
+		"tools": writeCheckout(t, map[string]string{
+			".github/CODEOWNERS": `
+# The docs team owns the documentation.
+*.md              @golang/docs
+/gopls/           @alice @bob
+/gopls/doc/       @golang/docs
+/gopls/go.sum
+`,
+			// OWNERS files are ignored where CODEOWNERS applies.
+			"gopls/OWNERS": "carol\n",
+		}),
+		"go": writeCheckout(t, map[string]string{
+			"OWNERS": "# Go team\nrsc@golang.org\n",
+			"src/cmd/go/OWNERS": `
+bcmills@google.com
+alice@golang.org
+per-file *.s=carol@golang.org
+`,
+			"src/cmd/compile/OWNERS": "set noparent\nkhr@golang.org\n",
+			".git/OWNERS":            "nobody@golang.org\n",
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestOwnershipArea(t *testing.T) {
+	o := testOwnership(t)
+	for _, tt := range []struct {
+		repo, file string
+		want       *generic.OwnedArea
+	}{
+		{"tools", "gopls/main.go", &generic.OwnedArea{Repo: "tools", Path: "/gopls/", Owners: []string{"@alice", "@bob"}}},
+		// The last matching line wins.
+		{"tools", "gopls/doc/settings.md", &generic.OwnedArea{Repo: "tools", Path: "/gopls/doc/", Owners: []string{"@golang/docs"}}},
+		{"tools", "README.md", &generic.OwnedArea{Repo: "tools", Path: "*.md", Owners: []string{"@golang/docs"}}},
+		// A line without owners leaves the files unowned.
+		{"tools", "gopls/go.sum", nil},
+		{"tools", "go/packages/packages.go", nil},
+		// OWNERS files include the owners of their parents.
+		{"go", "src/cmd/go/main.go", &generic.OwnedArea{Repo: "go", Path: "src/cmd/go/", Owners: []string{"bcmills@google.com", "alice@golang.org", "rsc@golang.org"}}},
+		{"go", "src/cmd/go/internal/modload/load.go", &generic.OwnedArea{Repo: "go", Path: "src/cmd/go/", Owners: []string{"bcmills@google.com", "alice@golang.org", "rsc@golang.org"}}},
+		// Unless they say "set noparent".
+		{"go", "src/cmd/compile/main.go", &generic.OwnedArea{Repo: "go", Path: "src/cmd/compile/", Owners: []string{"khr@golang.org"}}},
+		{"go", "src/fmt/print.go", &generic.OwnedArea{Repo: "go", Path: "/", Owners: []string{"rsc@golang.org"}}},
+		{"go", "README.md", &generic.OwnedArea{Repo: "go", Path: "/", Owners: []string{"rsc@golang.org"}}},
+		{"net", "http/server.go", nil},
+	} {
+		got := o.Area(tt.repo, tt.file)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Area(%q, %q) (-want +got):\n%s", tt.repo, tt.file, diff)
+		}
+	}
+}
+
+func TestOwnershipOwnerRepo(t *testing.T) {
+	// Gerrit CLs record only the project, such as "tools" for golang/tools.
+	checkouts, err := generic.ParseCheckouts("golang/tools=" + writeCheckout(t, map[string]string{
+		"CODEOWNERS": "/gopls/ @alice\n",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := generic.LoadOwnership(checkouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.Known("tools") {
+		t.Errorf(`Known("tools") = false, want true`)
+	}
+	if o.Known("go") {
+		t.Errorf(`Known("go") = true, want false`)
+	}
+	want := &generic.OwnedArea{Repo: "golang/tools", Path: "/gopls/", Owners: []string{"@alice"}}
+	if diff := cmp.Diff(want, o.Area("tools", "gopls/main.go")); diff != "" {
+		t.Errorf(`Area("tools", "gopls/main.go") (-want +got):\n%s`, diff)
+	}
+}
+
+func TestParseCheckouts(t *testing.T) {
+	got, err := generic.ParseCheckouts("/src/x/tools, golang/vscode-go=/src/vscode-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"tools":            "/src/x/tools",
+		"golang/vscode-go": "/src/vscode-go",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseCheckouts (-want +got):\n%s", diff)
+	}
+	for _, s := range []string{"=/src/tools", "tools=", "/a/tools,/b/tools"} {
+		if _, err := generic.ParseCheckouts(s); err == nil {
+			t.Errorf("ParseCheckouts(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestComputeOwnership(t *testing.T) {
+	o := testOwnership(t)
+	authored := []*generic.Changelist{
+		{Repo: "tools", Link: "1", AffectedFiles: []string{"gopls/main.go", "gopls/doc/settings.md", "go/packages/packages.go"}},
+		{Repo: "tools", Link: "2", AffectedFiles: []string{"gopls/main.go", "gopls/server.go"}},
+		// Duplicates, and changelists in repositories without a
+		// checkout, are left out.
+		{Repo: "tools", Link: "2", AffectedFiles: []string{"gopls/main.go", "gopls/server.go"}},
+		{Repo: "net", Link: "3", AffectedFiles: []string{"http/server.go"}},
+	}
+	reviewed := []*generic.Changelist{
+		{Repo: "go", Link: "4", AffectedFiles: []string{"src/cmd/go/main.go", "src/cmd/compile/main.go"}},
+	}
+	s := generic.ComputeOwnership(o, []string{"alice", "alice@golang.org"}, authored, reviewed)
+	want := &generic.OwnershipSummary{
+		Areas: []*generic.AreaWork{
+			{
+				OwnedArea: &generic.OwnedArea{Repo: "go", Path: "src/cmd/compile/", Owners: []string{"khr@golang.org"}},
+				WorkCount: generic.WorkCount{ReviewedCLs: 1, ReviewedFiles: 1},
+			},
+			{
+				OwnedArea: &generic.OwnedArea{Repo: "go", Path: "src/cmd/go/", Owners: []string{"bcmills@google.com", "alice@golang.org", "rsc@golang.org"}},
+				Yours:     true,
+				WorkCount: generic.WorkCount{ReviewedCLs: 1, ReviewedFiles: 1},
+			},
+			{
+				OwnedArea: &generic.OwnedArea{Repo: "tools"},
+				WorkCount: generic.WorkCount{AuthoredCLs: 1, AuthoredFiles: 1},
+			},
+			{
+				OwnedArea: &generic.OwnedArea{Repo: "tools", Path: "/gopls/", Owners: []string{"@alice", "@bob"}},
+				Yours:     true,
+				WorkCount: generic.WorkCount{AuthoredCLs: 2, AuthoredFiles: 3},
+			},
+			{
+				OwnedArea: &generic.OwnedArea{Repo: "tools", Path: "/gopls/doc/", Owners: []string{"@golang/docs"}},
+				WorkCount: generic.WorkCount{AuthoredCLs: 1, AuthoredFiles: 1},
+			},
+		},
+		Yours:   &generic.WorkCount{AuthoredCLs: 2, AuthoredFiles: 3, ReviewedCLs: 1, ReviewedFiles: 1},
+		Others:  &generic.WorkCount{AuthoredCLs: 1, AuthoredFiles: 1, ReviewedCLs: 1, ReviewedFiles: 1},
+		Unowned: &generic.WorkCount{AuthoredCLs: 1, AuthoredFiles: 1},
+		Total:   &generic.WorkCount{AuthoredCLs: 2, AuthoredFiles: 5, ReviewedCLs: 1, ReviewedFiles: 2},
+	}
+	if diff := cmp.Diff(want, s); diff != "" {
+		t.Errorf("ComputeOwnership (-want +got):\n%s", diff)
+	}
+
+	var got [][]string
+	for _, row := range generic.OwnershipToCells(s) {
+		cells := make([]string, 4)
+		for i, cell := range row.Cells {
+			if i < len(cells) {
+				cells[i] = cell.Text
+			}
+		}
+		got = append(got, cells)
+	}
+	wantRows := [][]string{
+		{"Repository", "Area", "Owners", "Authored CLs"},
+		{"Your areas", "", "", ""},
+		{"go", "src/cmd/go/", "bcmills@google.com, alice@golang.org, rsc@golang.org", "0"},
+		{"tools", "/gopls/", "@alice, @bob", "2"},
+		{"Subtotal", "Your areas", "", "2"},
+		{"Others' areas", "", "", ""},
+		{"go", "src/cmd/compile/", "khr@golang.org", "0"},
+		{"tools", "/gopls/doc/", "@golang/docs", "1"},
+		{"Subtotal", "Others' areas", "", "1"},
+		{"Unowned", "", "", ""},
+		{"tools", "(unowned)", "", "1"},
+		{"Subtotal", "Unowned", "", "1"},
+		{"Total", "", "", "2"},
+	}
+	if diff := cmp.Diff(wantRows, got); diff != "" {
+		t.Errorf("unexpected rows (-want +got):\n%s", diff)
+	}
+}
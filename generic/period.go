@@ -0,0 +1,119 @@
+package generic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	relativeRx = regexp.MustCompile(`^(\d+)([dw])$`)
+	quarterRx  = regexp.MustCompile(`^(\d{4})-[Qq]([1-4])$`)
+	halfRx     = regexp.MustCompile(`^(\d{4})-[Hh]([12])$`)
+	weekRx     = regexp.MustCompile(`^(\d{4})-[Ww](\d{1,2})$`)
+)
+
+// ParsePeriod parses a period expression and returns the time range that it
+// covers. Relative expressions are interpreted relative to now, and dates are
+// in now's location. A period is one of:
+//
+//	2006-01-02            a single day
+//	2006-01               a month
+//	2006                  a year
+//	2006-Q3               a quarter
+//	2006-H1               half of a year
+//	2006-W15              an ISO 8601 week, which starts on a Monday
+//	2006-01-02T15:04:05Z  an instant, in RFC 3339 format
+//	7d, 2w                the given number of days or weeks, up to now
+//	today, yesterday
+//	this-week, last-week, this-month, last-month,
+//	this-quarter, last-quarter, this-year, last-year
+//
+// Like every Range, a period excludes its End, which is the start of the
+// following period: the period "2006-01-31" ends at midnight on February 1.
+// So a period used as an end date includes the whole period.
+func ParsePeriod(now time.Time, period string) (Range, error) {
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	switch period {
+	case "today":
+		return Range{Start: today, End: today.AddDate(0, 0, 1)}, nil
+	case "yesterday":
+		return Range{Start: today.AddDate(0, 0, -1), End: today}, nil
+	case "this-week", "last-week":
+		start := startOfWeek(today)
+		if period == "last-week" {
+			start = start.AddDate(0, 0, -7)
+		}
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}, nil
+	case "this-month", "last-month":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+		if period == "last-month" {
+			start = start.AddDate(0, -1, 0)
+		}
+		return Range{Start: start, End: start.AddDate(0, 1, 0)}, nil
+	case "this-quarter", "last-quarter":
+		start := time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, loc)
+		if period == "last-quarter" {
+			start = start.AddDate(0, -3, 0)
+		}
+		return Range{Start: start, End: start.AddDate(0, 3, 0)}, nil
+	case "this-year", "last-year":
+		start := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, loc)
+		if period == "last-year" {
+			start = start.AddDate(-1, 0, 0)
+		}
+		return Range{Start: start, End: start.AddDate(1, 0, 0)}, nil
+	}
+	if m := relativeRx.FindStringSubmatch(period); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "w" {
+			n *= 7
+		}
+		return Range{Start: now.AddDate(0, 0, -n), End: now}, nil
+	}
+	if m := quarterRx.FindStringSubmatch(period); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		q, _ := strconv.Atoi(m[2])
+		start := time.Date(year, time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, loc)
+		return Range{Start: start, End: start.AddDate(0, 3, 0)}, nil
+	}
+	if m := halfRx.FindStringSubmatch(period); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		h, _ := strconv.Atoi(m[2])
+		start := time.Date(year, time.Month(6*(h-1)+1), 1, 0, 0, 0, 0, loc)
+		return Range{Start: start, End: start.AddDate(0, 6, 0)}, nil
+	}
+	if m := weekRx.FindStringSubmatch(period); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		// The first ISO week of a year is the one that contains January 4.
+		start := startOfWeek(time.Date(year, time.January, 4, 0, 0, 0, 0, loc)).AddDate(0, 0, 7*(week-1))
+		if y, w := start.ISOWeek(); week < 1 || y != year || w != week {
+			return Range{}, fmt.Errorf("invalid period %q: %d has no week %d", period, year, week)
+		}
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", period, loc); err == nil {
+		return Range{Start: t, End: t.AddDate(0, 0, 1)}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01", period, loc); err == nil {
+		return Range{Start: t, End: t.AddDate(0, 1, 0)}, nil
+	}
+	if t, err := time.ParseInLocation("2006", period, loc); err == nil {
+		return Range{Start: t, End: t.AddDate(1, 0, 0)}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, period); err == nil {
+		return Range{Start: t, End: t}, nil
+	}
+	return Range{}, fmt.Errorf("invalid period %q", period)
+}
+
+// startOfWeek returns the Monday on or before the given day.
+func startOfWeek(day time.Time) time.Time {
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
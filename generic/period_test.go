@@ -0,0 +1,72 @@
+package generic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestParsePeriod(t *testing.T) {
+	// Thursday, April 16, 2020.
+	now := time.Date(2020, time.April, 16, 15, 30, 0, 0, time.UTC)
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	for _, tt := range []struct {
+		period     string
+		start, end time.Time
+	}{
+		{"2020-02-28", day(2020, time.February, 28), day(2020, time.February, 29)},
+		{"2020-02", day(2020, time.February, 1), day(2020, time.March, 1)},
+		{"2019", day(2019, time.January, 1), day(2020, time.January, 1)},
+		{"2019-Q4", day(2019, time.October, 1), day(2020, time.January, 1)},
+		{"2020-H2", day(2020, time.July, 1), day(2021, time.January, 1)},
+		{"2020-W01", day(2019, time.December, 30), day(2020, time.January, 6)},
+		{"2020-W16", day(2020, time.April, 13), day(2020, time.April, 20)},
+		{"2021-W01", day(2021, time.January, 4), day(2021, time.January, 11)},
+		{"7d", now.AddDate(0, 0, -7), now},
+		{"2w", now.AddDate(0, 0, -14), now},
+		{"today", day(2020, time.April, 16), day(2020, time.April, 17)},
+		{"yesterday", day(2020, time.April, 15), day(2020, time.April, 16)},
+		{"this-week", day(2020, time.April, 13), day(2020, time.April, 20)},
+		{"last-week", day(2020, time.April, 6), day(2020, time.April, 13)},
+		{"this-month", day(2020, time.April, 1), day(2020, time.May, 1)},
+		{"last-month", day(2020, time.March, 1), day(2020, time.April, 1)},
+		{"this-quarter", day(2020, time.April, 1), day(2020, time.July, 1)},
+		{"last-quarter", day(2020, time.January, 1), day(2020, time.April, 1)},
+		{"this-year", day(2020, time.January, 1), day(2021, time.January, 1)},
+		{"last-year", day(2019, time.January, 1), day(2020, time.January, 1)},
+		{"2020-04-16T09:00:00Z", now.Add(-6*time.Hour - 30*time.Minute), now.Add(-6*time.Hour - 30*time.Minute)},
+	} {
+		r, err := generic.ParsePeriod(now, tt.period)
+		if err != nil {
+			t.Errorf("ParsePeriod(%q): %v", tt.period, err)
+			continue
+		}
+		if !r.Start.Equal(tt.start) || !r.End.Equal(tt.end) {
+			t.Errorf("ParsePeriod(%q) = %s, %s, want %s, %s", tt.period, r.Start, r.End, tt.start, tt.end)
+		}
+	}
+	for _, period := range []string{"", "soon", "2020-Q5", "2020-W54", "2021-W53", "2020-13"} {
+		if _, err := generic.ParsePeriod(now, period); err == nil {
+			t.Errorf("ParsePeriod(%q) succeeded, want error", period)
+		}
+	}
+}
+
+func TestParsePeriodLocation(t *testing.T) {
+	loc := time.FixedZone("UTC-7", -7*60*60)
+	// It is already Friday in UTC, but still Thursday in loc.
+	now := time.Date(2020, time.April, 17, 2, 0, 0, 0, time.UTC).In(loc)
+	r, err := generic.ParsePeriod(now, "today")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2020, time.April, 16, 0, 0, 0, 0, loc); !r.Start.Equal(want) {
+		t.Errorf("got start %s, want %s", r.Start, want)
+	}
+	if want := time.Date(2020, time.April, 17, 0, 0, 0, 0, loc); !r.End.Equal(want) {
+		t.Errorf("got end %s, want %s", r.End, want)
+	}
+}
@@ -0,0 +1,46 @@
+package generic
+
+import "time"
+
+// Range is the span of time from Start up to, but not including, End. Every
+// collector uses a Range to decide which events to report, so an event that
+// happens exactly at Start is in the range, and one that happens exactly at
+// End belongs to the following range instead.
+type Range struct {
+	Start, End time.Time
+}
+
+// Contains reports whether t is in the range.
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// In returns the same range with its times in loc.
+func (r Range) In(loc *time.Location) Range {
+	return Range{Start: r.Start.In(loc), End: r.End.In(loc)}
+}
+
+// Days returns midnight of each day that the range overlaps, in the range's
+// location. Days are found by calendar arithmetic, so days on which daylight
+// saving time begins or ends are included once each even though they are
+// not 24 hours long.
+func (r Range) Days() []time.Time {
+	var days []time.Time
+	loc := r.Start.Location()
+	end := r.End.In(loc)
+	for day := time.Date(r.Start.Year(), r.Start.Month(), r.Start.Day(), 0, 0, 0, 0, loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// String formats the range as its first and last days, such as
+// "2020-04-13 to 2020-04-19". Since the range excludes End, the last day is
+// the day before End if End is at midnight.
+func (r Range) String() string {
+	last := r.End.Add(-time.Nanosecond).In(r.Start.Location())
+	if last.Before(r.Start) {
+		last = r.Start
+	}
+	return r.Start.Format("2006-01-02") + " to " + last.Format("2006-01-02")
+}
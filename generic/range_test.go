@@ -0,0 +1,83 @@
+package generic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestRangeContains(t *testing.T) {
+	start := time.Date(2020, time.April, 13, 0, 0, 0, 0, time.UTC)
+	r := generic.Range{Start: start, End: start.AddDate(0, 0, 7)}
+	for _, tt := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{start.Add(-time.Nanosecond), false},
+		{start, true},
+		{start.AddDate(0, 0, 3), true},
+		{r.End.Add(-time.Nanosecond), true},
+		{r.End, false},
+		// The same instant in another location.
+		{start.In(time.FixedZone("UTC-7", -7*60*60)), true},
+		{time.Time{}, false},
+	} {
+		if got := r.Contains(tt.t); got != tt.want {
+			t.Errorf("%s.Contains(%s) = %v, want %v", r, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestRangeDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Daylight saving time began on Sunday, March 8, 2020, so that week is
+	// an hour shorter than usual.
+	now := time.Date(2020, time.March, 6, 12, 0, 0, 0, loc)
+	r, err := generic.ParsePeriod(now, "this-week")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2020, time.March, 2, 0, 0, 0, 0, loc); !r.Start.Equal(want) {
+		t.Errorf("got start %s, want %s", r.Start, want)
+	}
+	if want := time.Date(2020, time.March, 9, 0, 0, 0, 0, loc); !r.End.Equal(want) {
+		t.Errorf("got end %s, want %s", r.End, want)
+	}
+	if got, want := r.End.Sub(r.Start), 7*24*time.Hour-time.Hour; got != want {
+		t.Errorf("got duration %s, want %s", got, want)
+	}
+	days := r.Days()
+	if len(days) != 7 {
+		t.Fatalf("got %d days, want 7: %v", len(days), days)
+	}
+	for i, day := range days {
+		if want := time.Date(2020, time.March, 2+i, 0, 0, 0, 0, loc); !day.Equal(want) {
+			t.Errorf("day %d: got %s, want %s", i, day, want)
+		}
+	}
+	if got, want := r.String(), "2020-03-02 to 2020-03-08"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	// Just before midnight on the last day is still in the range, even
+	// though it is less than 7*24 hours after the start.
+	if last := time.Date(2020, time.March, 8, 23, 59, 0, 0, loc); !r.Contains(last) {
+		t.Errorf("%s does not contain %s", r, last)
+	}
+
+	// Daylight saving time ended on Sunday, November 1, 2020, so that week
+	// is an hour longer than usual.
+	r, err = generic.ParsePeriod(now, "2020-W44")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.End.Sub(r.Start), 7*24*time.Hour+time.Hour; got != want {
+		t.Errorf("got duration %s, want %s", got, want)
+	}
+	if got := len(r.Days()); got != 7 {
+		t.Errorf("got %d days, want 7", got)
+	}
+}
@@ -0,0 +1,179 @@
+package generic
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stamblerre/sheets"
+)
+
+// ReviewRequest is a request for someone to review a changelist.
+type ReviewRequest struct {
+	Link        string
+	Repo        string
+	Subject     string
+	Author      string
+	Reviewer    string
+	Status      ChangelistStatus
+	RequestedAt time.Time
+	// RespondedAt is the time of the reviewer's first message or review
+	// after the request. It is zero if they have not responded.
+	RespondedAt time.Time
+}
+
+// TimeToFirstResponse reports how long the reviewer took to respond to the
+// request. The boolean is false if they have not responded.
+func (r *ReviewRequest) TimeToFirstResponse() (time.Duration, bool) {
+	if r.RespondedAt.IsZero() {
+		return 0, false
+	}
+	return r.RespondedAt.Sub(r.RequestedAt), true
+}
+
+// Outstanding reports whether the request is still waiting on the reviewer:
+// they have not responded, and the changelist is still open.
+func (r *ReviewRequest) Outstanding() bool {
+	if !r.RespondedAt.IsZero() {
+		return false
+	}
+	return r.Status != Merged && r.Status != Abandoned
+}
+
+// Responsiveness summarizes how quickly a reviewer responds to review
+// requests.
+type Responsiveness struct {
+	Requests    int
+	Responded   int
+	Outstanding []*ReviewRequest
+
+	MedianTimeToFirstResponse time.Duration
+	P90TimeToFirstResponse    time.Duration
+}
+
+// MarshalJSON writes durations in their human-readable form, as for Latency.
+func (r *Responsiveness) MarshalJSON() ([]byte, error) {
+	var median, p90 string
+	if r.Responded > 0 {
+		median = r.MedianTimeToFirstResponse.String()
+		p90 = r.P90TimeToFirstResponse.String()
+	}
+	return json.Marshal(struct {
+		Requests                  int              `json:"requests"`
+		Responded                 int              `json:"responded"`
+		Outstanding               []*ReviewRequest `json:"outstanding,omitempty"`
+		MedianTimeToFirstResponse string           `json:"median_time_to_first_response,omitempty"`
+		P90TimeToFirstResponse    string           `json:"p90_time_to_first_response,omitempty"`
+	}{
+		Requests:                  r.Requests,
+		Responded:                 r.Responded,
+		Outstanding:               r.Outstanding,
+		MedianTimeToFirstResponse: median,
+		P90TimeToFirstResponse:    p90,
+	})
+}
+
+// ComputeResponsiveness groups review requests by reviewer and summarizes
+// each reviewer's responsiveness. Requests for the same reviewer on the same
+// changelist are only counted once.
+func ComputeResponsiveness(requests []*ReviewRequest) map[string]*Responsiveness {
+	type key struct{ reviewer, link string }
+	seen := make(map[key]bool)
+	byReviewer := make(map[string][]*ReviewRequest)
+	for _, r := range requests {
+		k := key{r.Reviewer, r.Link}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		byReviewer[r.Reviewer] = append(byReviewer[r.Reviewer], r)
+	}
+	result := make(map[string]*Responsiveness)
+	for reviewer, requests := range byReviewer {
+		sort.Slice(requests, func(i, j int) bool {
+			return requests[i].RequestedAt.Before(requests[j].RequestedAt)
+		})
+		resp := &Responsiveness{Requests: len(requests)}
+		var durations []time.Duration
+		for _, r := range requests {
+			if d, ok := r.TimeToFirstResponse(); ok {
+				durations = append(durations, d)
+			}
+			if r.Outstanding() {
+				resp.Outstanding = append(resp.Outstanding, r)
+			}
+		}
+		resp.Responded = len(durations)
+		resp.MedianTimeToFirstResponse = percentile(durations, 50)
+		resp.P90TimeToFirstResponse = percentile(durations, 90)
+		result[reviewer] = resp
+	}
+	return result
+}
+
+func ResponsivenessToCells(responsiveness map[string]*Responsiveness) []*sheets.Row {
+	if len(responsiveness) == 0 {
+		return nil
+	}
+	var reviewers []string
+	for reviewer := range responsiveness {
+		reviewers = append(reviewers, reviewer)
+	}
+	sort.Strings(reviewers)
+
+	rows := []*sheets.Row{{
+		Cells: []*sheets.Cell{
+			{Text: "Reviewer"},
+			{Text: "Review Requests"},
+			{Text: "Responded"},
+			{Text: "Median Time to First Response"},
+			{Text: "P90 Time to First Response"},
+			{Text: "Outstanding"},
+		},
+		BoldText: true,
+	}}
+	var outstanding []*ReviewRequest
+	for _, reviewer := range reviewers {
+		resp := responsiveness[reviewer]
+		median, p90 := "", ""
+		if resp.Responded > 0 {
+			median = formatDuration(resp.MedianTimeToFirstResponse)
+			p90 = formatDuration(resp.P90TimeToFirstResponse)
+		}
+		rows = append(rows, &sheets.Row{
+			Cells: []*sheets.Cell{
+				{Text: reviewer},
+				{Text: fmt.Sprint(resp.Requests)},
+				{Text: fmt.Sprint(resp.Responded)},
+				{Text: median},
+				{Text: p90},
+				{Text: fmt.Sprint(len(resp.Outstanding))},
+			},
+		})
+		outstanding = append(outstanding, resp.Outstanding...)
+	}
+	if len(outstanding) == 0 {
+		return rows
+	}
+	rows = append(rows, &sheets.Row{
+		Cells: []*sheets.Cell{
+			{Text: "Outstanding Review Request"},
+			{Text: "Reviewer"},
+			{Text: "Author"},
+			{Text: "Requested"},
+		},
+		BoldText: true,
+	})
+	for _, r := range outstanding {
+		rows = append(rows, &sheets.Row{
+			Cells: []*sheets.Cell{
+				{Text: r.Link, Hyperlink: r.Link},
+				{Text: r.Reviewer},
+				{Text: r.Author},
+				{Text: r.RequestedAt.Format("2006-01-02")},
+			},
+		})
+	}
+	return rows
+}
@@ -0,0 +1,54 @@
+package generic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestComputeResponsiveness(t *testing.T) {
+	requested := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	request := func(link, reviewer string, status generic.ChangelistStatus, response time.Duration) *generic.ReviewRequest {
+		r := &generic.ReviewRequest{
+			Link:        link,
+			Reviewer:    reviewer,
+			Status:      status,
+			RequestedAt: requested,
+		}
+		if response != 0 {
+			r.RespondedAt = requested.Add(response)
+		}
+		return r
+	}
+	waiting := request("a/4", "alice", generic.New, 0)
+	requests := []*generic.ReviewRequest{
+		request("a/1", "alice", generic.Merged, 1*time.Hour),
+		request("a/2", "alice", generic.New, 5*time.Hour),
+		request("a/3", "alice", generic.Abandoned, 0),
+		waiting,
+		request("b/1", "bob", generic.Merged, 2*time.Hour),
+		// Duplicates are ignored.
+		request("a/1", "alice", generic.Merged, 1*time.Hour),
+	}
+	got := generic.ComputeResponsiveness(requests)
+	want := map[string]*generic.Responsiveness{
+		"alice": {
+			Requests:                  4,
+			Responded:                 2,
+			Outstanding:               []*generic.ReviewRequest{waiting},
+			MedianTimeToFirstResponse: 1 * time.Hour,
+			P90TimeToFirstResponse:    5 * time.Hour,
+		},
+		"bob": {
+			Requests:                  1,
+			Responded:                 1,
+			MedianTimeToFirstResponse: 2 * time.Hour,
+			P90TimeToFirstResponse:    2 * time.Hour,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected responsiveness: %s", diff)
+	}
+}
@@ -0,0 +1,167 @@
+package generic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Rule assigns Category to the issues and changelists that match all of its
+// conditions. A condition with several values matches if any of them does,
+// and a rule without conditions matches everything.
+type Rule struct {
+	Category string `json:"category"`
+
+	// Labels match an issue's labels, or the labels of a changelist's
+	// associated issues.
+	Labels []string `json:"labels,omitempty"`
+	// TitlePrefixes match the start of an issue's title or a changelist's
+	// subject, such as "doc:" or "x/tools/gopls".
+	TitlePrefixes []string `json:"title_prefixes,omitempty"`
+	// Paths match the files that a changelist affects, either exactly or as
+	// a parent directory, such as "doc" or "internal/lsp/". Issues affect no
+	// files, so they never match a rule with Paths.
+	Paths []string `json:"paths,omitempty"`
+	// Repos match the repository, such as "golang/go". They also match
+	// Gerrit CLs, which record only the project, such as "go".
+	Repos []string `json:"repos,omitempty"`
+}
+
+// Rules classify issues and changelists into user-defined categories, such
+// as "bug", "feature", "docs", or "infra". The first rule that matches
+// decides the category.
+type Rules []*Rule
+
+// LoadRules reads rules from a JSON file containing a list of rules, such as:
+//
+//	[
+//		{"category": "feature", "labels": ["FeatureRequest"]},
+//		{"category": "docs", "title_prefixes": ["doc:"], "paths": ["doc/"]},
+//		{"category": "infra", "repos": ["golang/build"]},
+//		{"category": "bug"}
+//	]
+func LoadRules(filename string) (Rules, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return rules, nil
+}
+
+// ParseRules parses rules in the format read by LoadRules.
+func ParseRules(data []byte) (Rules, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var rules Rules
+	if err := dec.Decode(&rules); err != nil {
+		return nil, err
+	}
+	for i, rule := range rules {
+		if rule == nil || rule.Category == "" {
+			return nil, fmt.Errorf("rule %d has no category", i+1)
+		}
+	}
+	return rules, nil
+}
+
+// IssueCategory returns the category of the first rule that matches the
+// issue, or "" if none does.
+func (rules Rules) IssueCategory(issue *Issue) string {
+	for _, rule := range rules {
+		if rule.matches(issue.Repo, issue.Title, issue.Labels, nil) {
+			return rule.Category
+		}
+	}
+	return ""
+}
+
+// ChangelistCategory returns the category of the first rule that matches the
+// changelist, or "" if none does.
+func (rules Rules) ChangelistCategory(cl *Changelist) string {
+	var labels []string
+	for _, issue := range cl.AssociatedIssues {
+		labels = append(labels, issue.Labels...)
+	}
+	for _, rule := range rules {
+		if rule.matches(cl.Repo, cl.Subject, labels, cl.AffectedFiles) {
+			return rule.Category
+		}
+	}
+	return ""
+}
+
+// ClassifyIssues sets the Class of each issue that a rule matches, so that
+// it is reported under the rule's category.
+func (rules Rules) ClassifyIssues(issues []*Issue) {
+	for _, issue := range issues {
+		if category := rules.IssueCategory(issue); category != "" {
+			issue.Class = category
+		}
+	}
+}
+
+// ClassifyChangelists sets the Class of each changelist that a rule matches,
+// so that it is reported under the rule's category.
+func (rules Rules) ClassifyChangelists(cls []*Changelist) {
+	for _, cl := range cls {
+		if category := rules.ChangelistCategory(cl); category != "" {
+			cl.Class = category
+		}
+	}
+}
+
+// matches reports whether the rule's conditions are all met.
+func (rule *Rule) matches(repo, title string, labels, files []string) bool {
+	return condition(rule.Repos, func(r string) bool { return sameRepo(r, repo) }) &&
+		condition(rule.Labels, func(label string) bool { return contains(labels, label) }) &&
+		condition(rule.TitlePrefixes, func(prefix string) bool { return strings.HasPrefix(title, prefix) }) &&
+		condition(rule.Paths, func(path string) bool { return containsPath(files, path) })
+}
+
+// sameRepo reports whether repo, as recorded on an issue or changelist, is
+// the repository named "owner/repo". Gerrit CLs record only the project,
+// such as "tools" for golang/tools.
+func sameRepo(name, repo string) bool {
+	return name == repo || !strings.Contains(repo, "/") && name == "golang/"+repo
+}
+
+// condition reports whether any of values satisfies f. A condition without
+// values is always met.
+func condition(values []string, f func(string) bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if f(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, x string) bool {
+	for _, y := range s {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPath reports whether any of the files is path or is in the
+// directory path.
+func containsPath(files []string, path string) bool {
+	dir := strings.TrimSuffix(path, "/")
+	for _, file := range files {
+		if file == dir || strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package generic_test
+
+import (
+	"testing"
+
+	"github.com/stamblerre/work-stats/generic"
+)
+
+const testRules = `[
+	{"category": "feature", "labels": ["FeatureRequest", "Proposal"]},
+	{"category": "docs", "title_prefixes": ["doc:", "website:"]},
+	{"category": "docs", "paths": ["doc/"]},
+	{"category": "infra", "repos": ["golang/build"]},
+	{"category": "gopls-bug", "repos": ["golang/go"], "labels": ["gopls"]},
+	{"category": "bug"}
+]`
+
+func TestParseRules(t *testing.T) {
+	for _, tt := range []struct {
+		name, data string
+	}{
+		{"missing category", `[{"labels": ["FeatureRequest"]}]`},
+		{"null rule", `[null]`},
+		{"unknown field", `[{"category": "bug", "label": "NeedsFix"}]`},
+		{"not a list", `{"category": "bug"}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generic.ParseRules([]byte(tt.data)); err == nil {
+				t.Errorf("ParseRules(%s) succeeded, want an error", tt.data)
+			}
+		})
+	}
+}
+
+func TestIssueCategory(t *testing.T) {
+	rules, err := generic.ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		issue *generic.Issue
+		want  string
+	}{
+		{&generic.Issue{Repo: "golang/go", Title: "proposal: spec: generics", Labels: []string{"Proposal", "gopls"}}, "feature"},
+		{&generic.Issue{Repo: "golang/go", Title: "doc: fix typo"}, "docs"},
+		{&generic.Issue{Repo: "golang/website", Title: "website: broken link"}, "docs"},
+		// Issues affect no files.
+		{&generic.Issue{Repo: "golang/go", Title: "x/tools/gopls: crash", Labels: []string{"gopls"}}, "gopls-bug"},
+		{&generic.Issue{Repo: "golang/vscode-go", Title: "debug: crash", Labels: []string{"gopls"}}, "bug"},
+		{&generic.Issue{Repo: "golang/build", Title: "x/build: flaky builder"}, "infra"},
+	} {
+		if got := rules.IssueCategory(tt.issue); got != tt.want {
+			t.Errorf("IssueCategory(%q) = %q, want %q", tt.issue.Title, got, tt.want)
+		}
+	}
+	var none generic.Rules
+	if got := none.IssueCategory(&generic.Issue{Title: "doc: fix typo"}); got != "" {
+		t.Errorf("IssueCategory without rules = %q, want \"\"", got)
+	}
+}
+
+func TestChangelistCategory(t *testing.T) {
+	rules, err := generic.ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		cl   *generic.Changelist
+		want string
+	}{
+		{&generic.Changelist{Repo: "golang/go", Subject: "cmd/go: add flag", AffectedFiles: []string{"src/cmd/go/main.go", "doc/go1.19.html"}}, "docs"},
+		// Paths match whole directories.
+		{&generic.Changelist{Repo: "golang/go", Subject: "cmd/go: add flag", AffectedFiles: []string{"docs/README"}}, "bug"},
+		{&generic.Changelist{Repo: "golang/go", Subject: "update doc", AffectedFiles: []string{"doc"}}, "docs"},
+		{&generic.Changelist{Repo: "golang/go", Subject: "fmt: add Append", AssociatedIssues: []*generic.Issue{{Labels: []string{"Proposal"}}}}, "feature"},
+		{&generic.Changelist{Repo: "golang/build", Subject: "dashboard: add builder"}, "infra"},
+		// Gerrit CLs record only the project.
+		{&generic.Changelist{Repo: "build", Subject: "dashboard: add builder"}, "infra"},
+		{&generic.Changelist{Repo: "stamblerre/build", Subject: "dashboard: add builder"}, "bug"},
+	} {
+		if got := rules.ChangelistCategory(tt.cl); got != tt.want {
+			t.Errorf("ChangelistCategory(%q, %v) = %q, want %q", tt.cl.Subject, tt.cl.AffectedFiles, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	rules := generic.Rules{{Category: "docs", TitlePrefixes: []string{"doc:"}, Paths: []string{"doc"}}}
+	issues := []*generic.Issue{
+		{Title: "doc: fix typo"},
+		{Title: "cmd/go: crash"},
+	}
+	cls := []*generic.Changelist{
+		{Subject: "doc: fix typo", AffectedFiles: []string{"doc/go_spec.html"}},
+		// The subject matches, but the files do not.
+		{Subject: "doc: fix typo", AffectedFiles: []string{"src/fmt/doc.go"}},
+	}
+	rules.ClassifyIssues(issues)
+	rules.ClassifyChangelists(cls)
+	// Classified changelists are reported under the rule's category, and the
+	// others under the category in their title. Issues affect no files, so
+	// the rule never matches them.
+	for i, want := range []string{"doc", "cmd/go"} {
+		if got := issues[i].Category(); got != want {
+			t.Errorf("issue %d: Category() = %q, want %q", i, got, want)
+		}
+	}
+	for i, want := range []string{"docs", "doc"} {
+		if got := cls[i].Category(); got != want {
+			t.Errorf("changelist %d: Category() = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package generic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// snippetFormats maps the names of the built-in snippet formats to their
+// templates.
+var snippetFormats = map[string]string{
+	"markdown": DefaultSnippetTemplate,
+	"text":     textSnippetTemplate,
+	"html":     htmlSnippetTemplate,
+	"slack":    slackSnippetTemplate,
+	"gdocs":    gdocsSnippetTemplate,
+}
+
+// SnippetFormats returns the names of the built-in snippet formats.
+func SnippetFormats() []string {
+	var formats []string
+	for format := range snippetFormats {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// SnippetFormat returns the built-in template for the named format, which is
+// one of the names returned by SnippetFormats.
+func SnippetFormat(format string) (*template.Template, error) {
+	text, ok := snippetFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown snippet format %q (want one of %s)", format, strings.Join(SnippetFormats(), ", "))
+	}
+	return ParseSnippetTemplate(format, text)
+}
+
+// textSnippetTemplate is plain text, suitable for email.
+const textSnippetTemplate = `{{define "changelist"}}{{.Subject}} ({{url .Link}}{{with .Mirror}}, also {{url .}}{{end}}){{with .IssueSummary}} [{{.}}]{{end}}
+{{end}}
+{{- range .Sources}}
+{{- $name := .Name}}
+{{- $kind := .Kind}}
+{{- range .Sections}}{{if .Changelists}}{{$kind}}s {{lower .Title}}:
+{{if $.GroupChangelists}}{{range .Groups}}  {{.}}:
+{{range .Changelists}}    - {{template "changelist" .}}{{end}}{{end}}
+{{else}}{{range .Changelists}}  - {{template "changelist" .}}{{end}}
+{{end}}{{end}}{{end}}
+{{- if $.CollapseIssues}}{{with .Issues}}Commented on {{len .}} {{$name}} issues.
+
+{{end}}{{else}}{{with .IssueGroups}}{{$name}} issues:
+{{range .}}  {{.}}:
+{{range .Issues}}    - {{.Title}} ({{url .Link}}){{with .Actions}} [{{.}}]{{end}}
+{{end}}{{end}}
+{{end}}{{end}}
+{{- with .Discussions}}{{if $.CollapseIssues}}Participated in {{len .}} {{$name}} discussions.
+
+{{else}}{{$name}} discussions:
+{{range .}}  - {{.Title}} ({{url .Link}}){{with .Actions}} [{{.}}]{{end}}
+{{end}}
+{{end}}{{end}}
+{{- end}}`
+
+// htmlSnippetTemplate is an HTML fragment.
+const htmlSnippetTemplate = `{{define "changelist"}}<li><a href="{{html (url .Link)}}">{{html .Ref}}</a>{{with .Mirror}} (also <a href="{{html (url .)}}">{{html $.MirrorRef}}</a>){{end}}: {{html .Subject}}{{with .IssueSummary}} ({{html .}}){{end}}</li>
+{{end}}
+{{- range .Sources}}
+{{- $name := .Name}}
+{{- $kind := .Kind}}
+{{- range .Sections}}{{if .Changelists}}<h2>{{$kind}}s {{.Title}}</h2>
+{{if $.GroupChangelists}}{{range .Groups}}<h3>{{html .String}}</h3>
+<ul>
+{{range .Changelists}}{{template "changelist" .}}{{end}}</ul>
+{{end}}{{else}}<ul>
+{{range .Changelists}}{{template "changelist" .}}{{end}}</ul>
+{{end}}{{end}}{{end}}
+{{- if $.CollapseIssues}}{{with .Issues}}<h3>Commented on {{len .}} {{html $name}} issues</h3>
+{{end}}{{else}}{{with .IssueGroups}}<h2>{{html $name}} Issues</h2>
+{{range .}}<h3>{{html .String}}</h3>
+<ul>
+{{range .Issues}}<li><a href="{{html (url .Link)}}">{{html .Ref}}</a>: {{html .Title}}{{with .Actions}} ({{.}}){{end}}</li>
+{{end}}</ul>
+{{end}}{{end}}{{end}}
+{{- with .Discussions}}{{if $.CollapseIssues}}<h3>Participated in {{len .}} {{html $name}} discussions</h3>
+{{else}}<h2>{{html $name}} Discussions</h2>
+<ul>
+{{range .}}<li><a href="{{html (url .Link)}}">{{html .Ref}}</a>: {{html .Title}}{{with .Actions}} ({{.}}){{end}}</li>
+{{end}}</ul>
+{{end}}{{end}}
+{{- end}}`
+
+// slackSnippetTemplate uses Slack's mrkdwn syntax.
+const slackSnippetTemplate = `{{define "changelist"}}• <{{url .Link}}|{{slack .Ref}}>{{with .Mirror}} (also <{{url .}}|{{slack $.MirrorRef}}>){{end}}: {{slack .Subject}}{{with .IssueSummary}} ({{slack .}}){{end}}
+{{end}}
+{{- range .Sources}}
+{{- $name := .Name}}
+{{- $kind := .Kind}}
+{{- range .Sections}}{{if .Changelists}}*{{$kind}}s {{.Title}}*
+{{if $.GroupChangelists}}{{range .Groups}}_{{slack .String}}_
+{{range .Changelists}}{{template "changelist" .}}{{end}}{{end}}
+{{else}}{{range .Changelists}}{{template "changelist" .}}{{end}}
+{{end}}{{end}}{{end}}
+{{- if $.CollapseIssues}}{{with .Issues}}_Commented on {{len .}} {{$name}} issues_
+
+{{end}}{{else}}{{with .IssueGroups}}*{{$name}} Issues*
+{{range .}}_{{slack .String}}_
+{{range .Issues}}• <{{url .Link}}|{{slack .Ref}}>: {{slack .Title}}{{with .Actions}} ({{.}}){{end}}
+{{end}}{{end}}
+{{end}}{{end}}
+{{- with .Discussions}}{{if $.CollapseIssues}}_Participated in {{len .}} {{$name}} discussions_
+
+{{else}}*{{$name}} Discussions*
+{{range .}}• <{{url .Link}}|{{slack .Ref}}>: {{slack .Title}}{{with .Actions}} ({{.}}){{end}}
+{{end}}
+{{end}}{{end}}
+{{- end}}`
+
+// gdocsSnippetTemplate is plain text that pastes cleanly into Google Docs,
+// which turns the URLs into links.
+const gdocsSnippetTemplate = `{{define "changelist"}}• {{.Subject}} {{url .Link}}{{with .Mirror}} (also {{url .}}){{end}}{{with .IssueSummary}} ({{.}}){{end}}
+{{end}}
+{{- range .Sources}}
+{{- $name := .Name}}
+{{- $kind := .Kind}}
+{{- range .Sections}}{{if .Changelists}}{{$kind}}s {{.Title}}
+{{if $.GroupChangelists}}{{range .Groups}}{{.}}
+{{range .Changelists}}{{template "changelist" .}}{{end}}{{end}}
+{{else}}{{range .Changelists}}{{template "changelist" .}}{{end}}
+{{end}}{{end}}{{end}}
+{{- if $.CollapseIssues}}{{with .Issues}}Commented on {{len .}} {{$name}} issues
+
+{{end}}{{else}}{{with .IssueGroups}}{{$name}} Issues
+{{range .}}{{.}}
+{{range .Issues}}• {{.Title}} {{url .Link}}{{with .Actions}} ({{.}}){{end}}
+{{end}}{{end}}
+{{end}}{{end}}
+{{- with .Discussions}}{{if $.CollapseIssues}}Participated in {{len .}} {{$name}} discussions
+
+{{else}}{{$name}} Discussions
+{{range .}}• {{.Title}} {{url .Link}}{{with .Actions}} ({{.}}){{end}}
+{{end}}
+{{end}}{{end}}
+{{- end}}`
+
+// slackEscape escapes the characters that Slack treats as control
+// characters in mrkdwn.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
@@ -1,38 +1,327 @@
 package generic
 
 import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 )
 
+// Snippets is the data from which a weekly snippet report is rendered.
+type Snippets struct {
+	Start, End time.Time
+	Sources    []*SnippetSource
+	// CollapseIssues asks templates to report only the number of issues
+	// in each source, rather than listing them.
+	CollapseIssues bool
+	// GroupChangelists asks templates to group changelists by repository
+	// and category, rather than listing them in a flat list.
+	GroupChangelists bool
+}
+
+// SnippetSource is the user's activity in a single source, such as the Go
+// project's Gerrit or GitHub.
+type SnippetSource struct {
+	// Name describes the source's issues, such as "golang/go".
+	Name string
+	// Kind is what the source calls a changelist, such as "CL" or "PR".
+	Kind string
+
+	Merged     []*Changelist
+	InProgress []*Changelist
+	Reviewed   []*Changelist
+	Issues     []*Issue
+	// IssueGroups holds the same issues as Issues, grouped by repository and
+	// category.
+	IssueGroups []*IssueGroup
+	// Discussions are the discussions that the user participated in, if the
+	// source has any.
+	Discussions []*Discussion
+}
+
+// ChangelistSection is a set of changelists reported under one heading.
+type ChangelistSection struct {
+	// Title is "Merged", "In Progress", or "Reviewed".
+	Title       string
+	Changelists []*Changelist
+}
+
+// Sections returns the source's merged, in-progress, and reviewed
+// changelists, in that order.
+func (s *SnippetSource) Sections() []*ChangelistSection {
+	return []*ChangelistSection{
+		{Title: "Merged", Changelists: s.Merged},
+		{Title: "In Progress", Changelists: s.InProgress},
+		{Title: "Reviewed", Changelists: s.Reviewed},
+	}
+}
+
+// ChangelistGroup is the set of changelists in a repository and category.
+type ChangelistGroup struct {
+	Repo        string
+	Category    string
+	Changelists []*Changelist
+}
+
+func (g *ChangelistGroup) String() string {
+	if g.Category == "" {
+		return g.Repo
+	}
+	return g.Repo + ": " + g.Category
+}
+
+// Groups groups the section's changelists by repository and category. A
+// changelist with several components is in the group of each.
+func (s *ChangelistSection) Groups() []*ChangelistGroup {
+	type groupKey struct{ repo, category string }
+	groups := make(map[groupKey]*ChangelistGroup)
+	var result []*ChangelistGroup
+	for _, cl := range s.Changelists {
+		for _, category := range cl.Categories() {
+			key := groupKey{cl.Repo, category}
+			g, ok := groups[key]
+			if !ok {
+				g = &ChangelistGroup{Repo: key.repo, Category: key.category}
+				groups[key] = g
+				result = append(result, g)
+			}
+			g.Changelists = append(g.Changelists, cl)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Repo != result[j].Repo {
+			return result[i].Repo < result[j].Repo
+		}
+		return result[i].Category < result[j].Category
+	})
+	return result
+}
+
+// IssueGroup is the set of issues in a repository and category.
+type IssueGroup struct {
+	Repo     string
+	Category string
+	Issues   []*SnippetIssue
+}
+
+func (g *IssueGroup) String() string {
+	if g.Category == "" {
+		return g.Repo
+	}
+	return g.Repo + ": " + g.Category
+}
+
+// SnippetIssue is an issue along with what the user did on it during the
+// snippet's week.
+type SnippetIssue struct {
+	*Issue
+	Opened, Closed, Commented, Triaged bool
+	Reacted, Mentioned                 bool
+}
+
+// Actions describes what the user did on the issue, such as
+// "opened, closed".
+func (i *SnippetIssue) Actions() string {
+	var actions []string
+	if i.Opened {
+		actions = append(actions, "opened")
+	}
+	if i.Closed {
+		actions = append(actions, "closed")
+	}
+	if i.Commented {
+		actions = append(actions, "commented")
+	}
+	if i.Triaged {
+		actions = append(actions, "triaged")
+	}
+	if i.Reacted {
+		actions = append(actions, "reacted")
+	}
+	if i.Mentioned {
+		actions = append(actions, "mentioned")
+	}
+	return strings.Join(actions, ", ")
+}
+
+// NewSnippetSource splits the authored changelists into those merged before
+// the end of r and those still in progress, and groups the issues by
+// repository and category.
+func NewSnippetSource(name, kind, username string, r Range, authored, reviewed []*Changelist, issues []*Issue) *SnippetSource {
+	src := &SnippetSource{
+		Name:     name,
+		Kind:     kind,
+		Reviewed: reviewed,
+		Issues:   issues,
+	}
+	for _, cl := range authored {
+		if IsMergedBefore(cl, r.End) {
+			src.Merged = append(src.Merged, cl)
+		} else {
+			src.InProgress = append(src.InProgress, cl)
+		}
+	}
+	type groupKey struct{ repo, category string }
+	groups := make(map[groupKey]*IssueGroup)
+	for _, issue := range issues {
+		key := groupKey{issue.Repo, issue.Category()}
+		g, ok := groups[key]
+		if !ok {
+			g = &IssueGroup{Repo: key.repo, Category: key.category}
+			groups[key] = g
+			src.IssueGroups = append(src.IssueGroups, g)
+		}
+		g.Issues = append(g.Issues, &SnippetIssue{
+			Issue:     issue,
+			Opened:    issue.OpenedByUser(username) && r.Contains(issue.DateOpened),
+			Closed:    issue.ClosedByUser(username) && r.Contains(issue.DateClosed),
+			Commented: issue.Comments > 0,
+			Triaged:   len(issue.Triage) > 0,
+			Reacted:   issue.Reactions > 0,
+			Mentioned: issue.Mentions > 0,
+		})
+	}
+	sort.Slice(src.IssueGroups, func(i, j int) bool {
+		gi, gj := src.IssueGroups[i], src.IssueGroups[j]
+		if gi.Repo != gj.Repo {
+			return gi.Repo < gj.Repo
+		}
+		return gi.Category < gj.Category
+	})
+	for _, g := range src.IssueGroups {
+		sort.Slice(g.Issues, func(i, j int) bool {
+			return g.Issues[i].Number < g.Issues[j].Number
+		})
+	}
+	return src
+}
+
+// DefaultSnippetTemplate is the built-in markdown layout for snippets.
+const DefaultSnippetTemplate = `{{define "changelist"}}* {{link .}}{{with .Mirror}} (also [{{$.MirrorRef}}]({{url .}})){{end}}: {{.Subject}}{{with .IssueSummary}} ({{.}}){{end}}
+{{end}}
+{{- range .Sources}}
+{{- $name := .Name}}
+{{- $kind := .Kind}}
+{{- range .Sections}}{{if .Changelists}}## {{$kind}}s {{.Title}}
+
+{{if $.GroupChangelists}}{{range .Groups}}### {{.}}
+
+{{range .Changelists}}{{template "changelist" .}}{{end}}
+{{end}}{{else}}{{range .Changelists}}{{template "changelist" .}}{{end}}
+{{end}}{{end}}{{end}}
+{{- if $.CollapseIssues}}{{with .Issues}}### Commented on {{len .}} {{$name}} issues
+
+{{end}}{{else}}{{with .IssueGroups}}## {{$name}} Issues
+
+{{range .}}### {{.}}
+
+{{range .Issues}}* [{{.Ref}}]({{url .Link}}): {{.Title}}{{with .Actions}} ({{.}}){{end}}
+{{end}}
+{{end}}{{end}}{{end}}
+{{- with .Discussions}}{{if $.CollapseIssues}}### Participated in {{len .}} {{$name}} discussions
+
+{{else}}## {{$name}} Discussions
+
+{{range .}}* [{{.Ref}}]({{url .Link}}): {{.Title}}{{with .Actions}} ({{.}}){{end}}
+{{end}}
+{{end}}{{end}}
+{{- end}}`
+
+// ParseSnippetTemplate parses a snippet template. In addition to the
+// standard template functions, templates may use:
+//
+//	link   a markdown link to a changelist, such as "[1234](https://...)"
+//	url    the full URL of a changelist or issue link
+//	date   a time formatted as 2006-01-02
+//	slack  text escaped for Slack mrkdwn
+//	lower  text in lower case
+func ParseSnippetTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{
+		"link": func(cl *Changelist) string {
+			return fmt.Sprintf("[%s](%s)", cl.Ref(), URL(cl.Link))
+		},
+		"url": URL,
+		"date": func(t time.Time) string {
+			return t.Format("2006-01-02")
+		},
+		"slack": slackEscape,
+		"lower": strings.ToLower,
+	}).Parse(text)
+}
+
+// WriteSnippets renders the snippets to w with the given template.
+func WriteSnippets(w io.Writer, tmpl *template.Template, s *Snippets) error {
+	return tmpl.Execute(w, s)
+}
+
+// Ref returns a short reference to the changelist: its number for a Gerrit
+// CL, or "owner/repo#number" for a GitHub PR.
+func (cl *Changelist) Ref() string {
+	if strings.Contains(cl.Repo, "/") {
+		return fmt.Sprintf("%s#%d", cl.Repo, cl.Number)
+	}
+	return fmt.Sprint(cl.Number)
+}
+
+// IssueSummary describes the issues associated with the changelist, such
+// as "fixes golang/go#100, updates golang/go#101".
+func (cl *Changelist) IssueSummary() string {
+	var refs []string
+	for _, issue := range cl.AssociatedIssues {
+		verb := "updates"
+		if cl.fixes(issue) {
+			verb = "fixes"
+		}
+		refs = append(refs, verb+" "+issue.Ref())
+	}
+	return strings.Join(refs, ", ")
+}
+
+// fixes reports whether the changelist's message has a line such as
+// "Fixes golang/go#100" that closes the issue.
+func (cl *Changelist) fixes(issue *Issue) bool {
+	ref := fmt.Sprintf("#%d", issue.Number)
+	for _, line := range strings.Split(cl.Message, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSuffix(fields[0], ":")) {
+		case "fixes", "fix", "closes", "close", "resolves", "resolve":
+		default:
+			continue
+		}
+		for _, f := range fields[1:] {
+			f = strings.TrimRight(f, ".,;")
+			if strings.HasSuffix(f, ref) && (f == ref || strings.HasSuffix(f, issue.Repo+ref)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// URL returns link with an https scheme if it does not already have one.
+// Gerrit and maintner links are stored without a scheme.
+func URL(link string) string {
+	if strings.HasPrefix(link, "https://") || strings.HasPrefix(link, "http://") {
+		return link
+	}
+	return "https://" + link
+}
+
 // InferTimeRange gets the start and end time for a weekly snippet report.
 // If the optional weekOf parameter is provided, the time range is for the
-// week in which the date falls, not inferred.
-func InferTimeRange(now time.Time, weekOf string) (start, end time.Time, err error) {
-	if weekOf != "" {
-		t, err := time.Parse("2006-01-02", weekOf)
-		if err != nil {
-			return time.Time{}, time.Time{}, err
-		}
-		now = t
-	} else {
-		// If this command is running Mon-Wed, assume that it's for the previous
-		// week and look for the preceding Monday. If the command is running
-		// Thurs-Sunday, assume that it is for the current week.
-		switch now.Weekday() {
-		case time.Monday:
-			now = now.AddDate(0, 0, -1)
-		case time.Tuesday:
-			now = now.AddDate(0, 0, -2)
-		case time.Wednesday:
-			now = now.AddDate(0, 0, -3)
-		}
-	}
-	start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	for start.Weekday() != time.Monday {
-		start = start.AddDate(0, 0, -1)
-	}
-	end = start.AddDate(0, 0, 7)
-	return start, end, nil
+// week in which its period starts, not inferred. weekOf may be any period
+// accepted by ParsePeriod, such as "2020-04-16", "last-week", or "2020-W16".
+//
+// If this command is running Mon-Wed, assume that it's for the previous
+// week. If the command is running Thurs-Sunday, assume that it is for the
+// current week. See Cadence for other schedules.
+func InferTimeRange(now time.Time, weekOf string) (Range, error) {
+	return DefaultCadence.InferTimeRange(now, weekOf)
 }
 
 func IsMergedBefore(cl *Changelist, end time.Time) bool {
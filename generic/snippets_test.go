@@ -1,12 +1,102 @@
 package generic_test
 
 import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/stamblerre/work-stats/generic"
 )
 
+var update = flag.Bool("update", false, "update golden files")
+
+func TestSnippetFormats(t *testing.T) {
+	extensions := map[string]string{
+		"markdown": ".md",
+		"html":     ".html",
+	}
+	for _, format := range generic.SnippetFormats() {
+		for _, grouped := range []bool{false, true} {
+			name := format
+			if grouped {
+				name += "-grouped"
+			}
+			t.Run(name, func(t *testing.T) {
+				tmpl, err := generic.SnippetFormat(format)
+				if err != nil {
+					t.Fatal(err)
+				}
+				s := testSnippets()
+				s.GroupChangelists = grouped
+				var b strings.Builder
+				if err := generic.WriteSnippets(&b, tmpl, s); err != nil {
+					t.Fatal(err)
+				}
+				ext, ok := extensions[format]
+				if !ok {
+					ext = ".txt"
+				}
+				golden := filepath.Join("testdata", "snippets", name+ext)
+				if *update {
+					if err := ioutil.WriteFile(golden, []byte(b.String()), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(string(want), b.String()); diff != "" {
+					t.Errorf("output does not match %s (-want +got):\n%s", golden, diff)
+				}
+			})
+		}
+	}
+	if _, err := generic.SnippetFormat("latex"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestIssueSummary(t *testing.T) {
+	issue := func(n int) *generic.Issue {
+		return &generic.Issue{Number: n, Repo: "golang/go"}
+	}
+	for _, tt := range []struct {
+		message string
+		issues  []*generic.Issue
+		want    string
+	}{
+		{
+			message: "cmd/go: fix build\n\nFixes #100\n",
+			issues:  []*generic.Issue{issue(100)},
+			want:    "fixes golang/go#100",
+		},
+		{
+			message: "gopls: fix hover\n\nFixes golang/go#100.\nUpdates golang/go#1000\n",
+			issues:  []*generic.Issue{issue(100), issue(1000)},
+			want:    "fixes golang/go#100, updates golang/go#1000",
+		},
+		{
+			// golang/go#10 is a prefix of golang/go#100, but isn't fixed.
+			message: "gopls: fix hover\n\nFixes golang/go#100\nFor golang/go#10\n",
+			issues:  []*generic.Issue{issue(10)},
+			want:    "updates golang/go#10",
+		},
+		{
+			message: "gopls: refactor\n",
+		},
+	} {
+		cl := &generic.Changelist{Message: tt.message, AssociatedIssues: tt.issues}
+		if got := cl.IssueSummary(); got != tt.want {
+			t.Errorf("IssueSummary(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
 func TestInferTimeRange(t *testing.T) {
 	for _, tt := range []struct {
 		date, weekOf, start, end string
@@ -60,6 +150,20 @@ func TestInferTimeRange(t *testing.T) {
 			start:  "2020-04-13",
 			end:    "2020-04-20",
 		},
+		{
+			// Week of is a relative period
+			date:   "2020-04-16",
+			weekOf: "last-week",
+			start:  "2020-04-06",
+			end:    "2020-04-13",
+		},
+		{
+			// Week of is an ISO week
+			date:   "2020-04-16",
+			weekOf: "2020-W18",
+			start:  "2020-04-27",
+			end:    "2020-05-04",
+		},
 	} {
 		now, err := time.Parse("2006-01-02", tt.date)
 		if err != nil {
@@ -73,15 +177,114 @@ func TestInferTimeRange(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		start, end, err := generic.InferTimeRange(now, tt.weekOf)
+		r, err := generic.InferTimeRange(now, tt.weekOf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Start.Equal(wantStart) {
+			t.Errorf("%s: wanted start %s, got %s", now, wantStart, r.Start)
+		}
+		if !r.End.Equal(wantEnd) {
+			t.Errorf("%s: wanted end %s, got %s", now, wantEnd, r.End)
+		}
+	}
+}
+
+// testSnippets returns alice's snippets for the week of March 7, 2022.
+func testSnippets() *generic.Snippets {
+	start := time.Date(2022, time.March, 7, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	hover := &generic.Issue{Number: 100, Link: "github.com/golang/go/issues/100", Repo: "golang/go", Title: "x/tools/internal/lsp: hover is broken", OpenedBy: "alice", ClosedBy: "alice", DateOpened: start.AddDate(0, 0, 1), DateClosed: start.AddDate(0, 0, 3)}
+	build := &generic.Issue{Number: 101, Link: "github.com/golang/go/issues/101", Repo: "golang/go", Title: "cmd/go: build fails", OpenedBy: "bob", DateOpened: start, Comments: 2}
+	golang := generic.NewSnippetSource("golang/go", "CL", "alice", generic.Range{Start: start, End: end}, []*generic.Changelist{
+		{
+			Number:           1001,
+			Link:             "go-review.googlesource.com/c/tools/+/1001",
+			Repo:             "tools",
+			Subject:          "internal/lsp: fix hover",
+			Message:          "internal/lsp: fix hover\n\nFixes golang/go#100\nUpdates golang/go#101\n",
+			Status:           generic.Merged,
+			MergedAt:         end.AddDate(0, 0, -1),
+			AssociatedIssues: []*generic.Issue{hover, build},
+		},
+		{Number: 1004, Link: "go-review.googlesource.com/c/tools/+/1004", Repo: "tools", Subject: "gopls: add a setting", Status: generic.Merged, MergedAt: end.AddDate(0, 0, -2), Mirror: "https://github.com/golang/tools/pull/12"},
+		{Number: 1005, Link: "go-review.googlesource.com/c/tools/+/1005", Repo: "tools", Subject: "internal/lsp: clean up", Status: generic.Merged, MergedAt: end.AddDate(0, 0, -2)},
+		{Number: 1002, Link: "go-review.googlesource.com/c/tools/+/1002", Repo: "tools", Subject: "internal/lsp: add a test", Status: generic.Merged, MergedAt: end.AddDate(0, 0, 1)},
+	}, []*generic.Changelist{
+		{Number: 1003, Link: "go-review.googlesource.com/c/tools/+/1003", Repo: "tools", Subject: "gopls: update docs", Status: generic.New},
+	}, []*generic.Issue{
+		build,
+		hover,
+		{Number: 5, Link: "github.com/golang/vscode-go/issues/5", Repo: "golang/vscode-go", Title: "debug: breakpoints are ignored", OpenedBy: "alice", DateOpened: start.AddDate(0, -1, 0), Comments: 1},
+	})
+	gh := generic.NewSnippetSource("GitHub", "PR", "alice", generic.Range{Start: start, End: end}, []*generic.Changelist{
+		{Number: 4, Link: "https://github.com/stamblerre/work-stats/pull/4", Repo: "stamblerre/work-stats", Subject: "snippets: render <html> & slack", Status: generic.Unknown},
+	}, nil, []*generic.Issue{
+		{Number: 2, Link: "https://github.com/stamblerre/sheets/issues/2", Repo: "stamblerre/sheets", Title: "crash on empty <sheet>", OpenedBy: "bob", ClosedBy: "alice", DateOpened: start.AddDate(0, 0, -3), DateClosed: start.AddDate(0, 0, 2), Reactions: 1},
+	})
+	gh.Discussions = []*generic.Discussion{
+		{Number: 7, Link: "https://github.com/stamblerre/work-stats/discussions/7", Repo: "stamblerre/work-stats", Title: "Ideas for <charts>", Started: true, Comments: 2},
+	}
+	return &generic.Snippets{
+		Start:   start,
+		End:     end,
+		Sources: []*generic.SnippetSource{golang, gh},
+	}
+}
+
+func TestWriteSnippets(t *testing.T) {
+	for _, tt := range []struct {
+		name, template, want string
+		collapse             bool
+	}{
+		{
+			name:     "collapsed",
+			template: generic.DefaultSnippetTemplate,
+			collapse: true,
+			want: `## CLs Merged
+
+* [1001](https://go-review.googlesource.com/c/tools/+/1001): internal/lsp: fix hover (fixes golang/go#100, updates golang/go#101)
+* [1004](https://go-review.googlesource.com/c/tools/+/1004) (also [golang/tools#12](https://github.com/golang/tools/pull/12)): gopls: add a setting
+* [1005](https://go-review.googlesource.com/c/tools/+/1005): internal/lsp: clean up
+
+## CLs In Progress
+
+* [1002](https://go-review.googlesource.com/c/tools/+/1002): internal/lsp: add a test
+
+## CLs Reviewed
+
+* [1003](https://go-review.googlesource.com/c/tools/+/1003): gopls: update docs
+
+### Commented on 3 golang/go issues
+
+## PRs In Progress
+
+* [stamblerre/work-stats#4](https://github.com/stamblerre/work-stats/pull/4): snippets: render <html> & slack
+
+### Commented on 1 GitHub issues
+
+### Participated in 1 GitHub discussions
+
+`,
+		},
+		{
+			name:     "custom",
+			template: `Week of {{date .Start}}:{{range .Sources}}{{range .Merged}} {{url .Link}}{{end}}{{end}}`,
+			want:     `Week of 2022-03-07: https://go-review.googlesource.com/c/tools/+/1001 https://go-review.googlesource.com/c/tools/+/1004 https://go-review.googlesource.com/c/tools/+/1005`,
+		},
+	} {
+		tmpl, err := generic.ParseSnippetTemplate(tt.name, tt.template)
 		if err != nil {
 			t.Fatal(err)
 		}
-		if !start.Equal(wantStart) {
-			t.Errorf("%s: wanted start %s, got %s", now, wantStart, start)
+		s := testSnippets()
+		s.CollapseIssues = tt.collapse
+		var b strings.Builder
+		if err := generic.WriteSnippets(&b, tmpl, s); err != nil {
+			t.Fatal(err)
 		}
-		if !end.Equal(wantEnd) {
-			t.Errorf("%s: wanted end %s, got %s", now, wantEnd, end)
+		if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+			t.Errorf("%s: unexpected snippets (-want +got):\n%s", tt.name, diff)
 		}
 	}
 }
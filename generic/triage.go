@@ -0,0 +1,174 @@
+package generic
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stamblerre/sheets"
+)
+
+// TriageKind is a kind of issue gardening.
+type TriageKind int
+
+const (
+	// Labeled is adding or removing a label other than a triage label.
+	Labeled = TriageKind(iota)
+	// Milestoned is adding an issue to a milestone or removing it from one.
+	Milestoned
+	// Assigned is assigning an issue or removing an assignee.
+	Assigned
+	// Triaged is adding or removing one of the TriageLabels.
+	Triaged
+	// Locked is locking or unlocking an issue's conversation.
+	Locked
+)
+
+var triageKinds = []TriageKind{Labeled, Milestoned, Assigned, Triaged, Locked}
+
+func (k TriageKind) String() string {
+	switch k {
+	case Labeled:
+		return "labeled"
+	case Milestoned:
+		return "milestoned"
+	case Assigned:
+		return "assigned"
+	case Triaged:
+		return "triaged"
+	case Locked:
+		return "locked"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, so that kinds are readable
+// in JSON output.
+func (k TriageKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// TriageLabels are the labels that record the progress of an issue through
+// triage in the Go project.
+var TriageLabels = map[string]bool{
+	"NeedsInvestigation": true,
+	"NeedsDecision":      true,
+	"NeedsFix":           true,
+	"WaitingForInfo":     true,
+}
+
+// TriageAction is something a user did to garden an issue, other than
+// opening, closing, or commenting on it.
+type TriageAction struct {
+	Kind TriageKind
+	// Detail is the label, milestone, or assignee involved, if any.
+	Detail string
+	Time   time.Time
+}
+
+// ToTriageAction converts a GitHub issue event, such as "labeled", to a
+// triage action. detail is the event's label, milestone, or assignee. It
+// returns false if the event is not triage, such as a "closed" event.
+func ToTriageAction(event, detail string, t time.Time) (*TriageAction, bool) {
+	var kind TriageKind
+	switch event {
+	case "labeled", "unlabeled":
+		kind = Labeled
+		if TriageLabels[detail] {
+			kind = Triaged
+		}
+	case "milestoned", "demilestoned":
+		kind = Milestoned
+	case "assigned", "unassigned":
+		kind = Assigned
+	case "locked", "unlocked":
+		kind = Locked
+	default:
+		return nil, false
+	}
+	return &TriageAction{Kind: kind, Detail: detail, Time: t}, true
+}
+
+// TriageCount returns the number of triage actions of the given kind that
+// the user took on the issue.
+func (issue Issue) TriageCount(kind TriageKind) int {
+	var n int
+	for _, a := range issue.Triage {
+		if a.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// TriageToCells lays out the triage actions that the user took on each
+// issue, by repository.
+func TriageToCells(issues []*Issue) []*sheets.Row {
+	repos := make(map[string][]*Issue)
+	for _, issue := range issues {
+		if len(issue.Triage) == 0 {
+			continue
+		}
+		repos[issue.Repo] = append(repos[issue.Repo], issue)
+	}
+	if len(repos) == 0 {
+		return nil
+	}
+	var sortedRepos []string
+	for repo := range repos {
+		sortedRepos = append(sortedRepos, repo)
+	}
+	sort.Strings(sortedRepos)
+
+	cells := []*sheets.Row{{
+		Cells: []*sheets.Cell{
+			{Text: "Issue Number"},
+			{Text: "Description"},
+			{Text: "Labeled"},
+			{Text: "Milestoned"},
+			{Text: "Assigned"},
+			{Text: "Triaged"},
+			{Text: "Locked"},
+			{Text: "Total Issues"},
+		},
+		BoldText: true,
+	}}
+	// The totals count the actions of each kind, followed by the issues.
+	total := make([]int, len(triageKinds)+1)
+	for _, repo := range sortedRepos {
+		issues := repos[repo]
+		sort.Slice(issues, func(i, j int) bool {
+			return issues[i].Link < issues[j].Link
+		})
+		subtotal := make([]int, len(triageKinds)+1)
+		for _, issue := range issues {
+			row := []*sheets.Cell{
+				{Text: issue.Link, Hyperlink: issue.Link},
+				{Text: truncate(issue.Title)},
+			}
+			for i, kind := range triageKinds {
+				n := issue.TriageCount(kind)
+				subtotal[i] += n
+				row = append(row, &sheets.Cell{Text: fmt.Sprint(n)})
+			}
+			row = append(row, &sheets.Cell{})
+			subtotal[len(triageKinds)]++
+			cells = append(cells, &sheets.Row{Cells: row})
+		}
+		cells = append(cells, sheets.TotalRow(append([]string{"Subtotal", repo}, counts(subtotal)...)...))
+		for i, n := range subtotal {
+			total[i] += n
+		}
+	}
+	cells = append(cells, sheets.TotalRow(append([]string{"Total", ""}, counts(total)...)...))
+	return cells
+}
+
+func counts(ns []int) []string {
+	var s []string
+	for _, n := range ns {
+		s = append(s, fmt.Sprint(n))
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package generic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+func TestToTriageAction(t *testing.T) {
+	at := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		event, detail string
+		want          *generic.TriageAction
+	}{
+		{"labeled", "gopls", &generic.TriageAction{Kind: generic.Labeled, Detail: "gopls", Time: at}},
+		{"unlabeled", "gopls", &generic.TriageAction{Kind: generic.Labeled, Detail: "gopls", Time: at}},
+		{"labeled", "NeedsInvestigation", &generic.TriageAction{Kind: generic.Triaged, Detail: "NeedsInvestigation", Time: at}},
+		{"unlabeled", "WaitingForInfo", &generic.TriageAction{Kind: generic.Triaged, Detail: "WaitingForInfo", Time: at}},
+		{"milestoned", "Go1.19", &generic.TriageAction{Kind: generic.Milestoned, Detail: "Go1.19", Time: at}},
+		{"demilestoned", "Go1.19", &generic.TriageAction{Kind: generic.Milestoned, Detail: "Go1.19", Time: at}},
+		{"assigned", "bob", &generic.TriageAction{Kind: generic.Assigned, Detail: "bob", Time: at}},
+		{"unassigned", "bob", &generic.TriageAction{Kind: generic.Assigned, Detail: "bob", Time: at}},
+		{"locked", "", &generic.TriageAction{Kind: generic.Locked, Time: at}},
+		{"closed", "", nil},
+		{"renamed", "", nil},
+	} {
+		got, ok := generic.ToTriageAction(tt.event, tt.detail, at)
+		if ok != (tt.want != nil) {
+			t.Errorf("ToTriageAction(%q, %q) returned %v, want %v", tt.event, tt.detail, ok, tt.want != nil)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("ToTriageAction(%q, %q) (-want +got):\n%s", tt.event, tt.detail, diff)
+		}
+	}
+}
+
+func TestTriageToCells(t *testing.T) {
+	issues := []*generic.Issue{
+		{Link: "go/2", Repo: "golang/go", Title: "cmd/go: crash", Triage: []*generic.TriageAction{
+			{Kind: generic.Triaged, Detail: "NeedsInvestigation"},
+			{Kind: generic.Milestoned, Detail: "Go1.19"},
+			{Kind: generic.Assigned, Detail: "bob"},
+		}},
+		{Link: "go/1", Repo: "golang/go", Title: "net: leak", Triage: []*generic.TriageAction{
+			{Kind: generic.Labeled, Detail: "Performance"},
+			{Kind: generic.Labeled, Detail: "OS-Linux"},
+		}},
+		// Issues without triage are left out.
+		{Link: "go/3", Repo: "golang/go", Title: "fmt: typo", Comments: 1},
+		{Link: "vscode-go/1", Repo: "golang/vscode-go", Title: "debug: hangs", Triage: []*generic.TriageAction{
+			{Kind: generic.Locked},
+		}},
+	}
+	var got [][]string
+	for _, row := range generic.TriageToCells(issues) {
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Text)
+		}
+		got = append(got, cells)
+	}
+	want := [][]string{
+		{"Issue Number", "Description", "Labeled", "Milestoned", "Assigned", "Triaged", "Locked", "Total Issues"},
+		{"go/1", "net: leak", "2", "0", "0", "0", "0", ""},
+		{"go/2", "cmd/go: crash", "0", "1", "1", "1", "0", ""},
+		{"Subtotal", "golang/go", "2", "1", "1", "1", "0", "2"},
+		{"vscode-go/1", "debug: hangs", "0", "0", "0", "0", "1", ""},
+		{"Subtotal", "golang/vscode-go", "0", "0", "0", "0", "1", "1"},
+		{"Total", "", "2", "1", "1", "1", "1", "3"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rows (-want +got):\n%s", diff)
+	}
+	if rows := generic.TriageToCells(issues[2:3]); rows != nil {
+		t.Errorf("got %d rows for issues without triage, want none", len(rows))
+	}
+}
@@ -0,0 +1,131 @@
+package github
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/stamblerre/work-stats/generic"
+)
+
+// ActivityCache remembers the user's comments, reactions, mentions, and
+// triage on GitHub issues and PRs between runs, so that the activity on
+// issues that have not been updated since it was last fetched is not fetched
+// again. Reactions do not update an issue, so they are fetched again for the
+// issue and the comments whose number of reactions changed.
+//
+// A nil *ActivityCache is valid and caches nothing.
+type ActivityCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*activity
+}
+
+// activity is the user's comments, reactions, mentions, and triage on an
+// issue, as of when it was last updated.
+type activity struct {
+	// Updated is when the issue was last updated when its activity was
+	// fetched.
+	Updated time.Time
+	// Since is the earliest time from which activity was fetched.
+	Since time.Time
+	// Comments are the times at which the user commented on the issue,
+	// including review comments on PRs.
+	Comments []time.Time
+	// Reactions are the reactions to the issue and its comments, by the
+	// API path at which they are listed.
+	Reactions map[string]*reactions `json:"ReactionsByPath,omitempty"`
+	// Mentions are the times at which someone else @-mentioned the user in
+	// the issue or its comments.
+	Mentions []time.Time `json:",omitempty"`
+	// Timeline is set if the issue's timeline was listed for Triage. The
+	// timelines of PRs are not listed.
+	Timeline bool `json:",omitempty"`
+	// Triage are the user's triage actions on the issue, such as labeling
+	// it.
+	Triage []*generic.TriageAction `json:",omitempty"`
+}
+
+// reactions are the reactions to an issue or comment.
+type reactions struct {
+	// Total is the number of reactions, by anyone.
+	Total int
+	// Times are the times at which the user reacted.
+	Times []time.Time `json:",omitempty"`
+}
+
+// DefaultActivityCachePath returns where the activity cache is stored by
+// default, in the user's cache directory, or "" if there is none.
+func DefaultActivityCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "work-stats", "github-activity.json")
+}
+
+// OpenActivityCache reads the cache stored at path. If there is no file at
+// path, the cache starts out empty.
+func OpenActivityCache(path string) (*ActivityCache, error) {
+	c := &ActivityCache{
+		path:    path,
+		entries: make(map[string]*activity),
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the cache back to the file it was read from.
+func (c *ActivityCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, data, 0644)
+}
+
+// lookup returns the user's activity on the issue with the given key, if any,
+// and whether it was cached when the issue was last updated, at or before
+// since.
+func (c *ActivityCache) lookup(key string, updated, since time.Time) (*activity, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return a, a.Updated.Equal(updated) && !a.Since.After(since)
+}
+
+func (c *ActivityCache) store(key string, a *activity) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = a
+}
@@ -0,0 +1,213 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+// activityCounts is the user's participation in an issue or PR during the
+// reporting period.
+type activityCounts struct {
+	comments, reactions, mentions int
+	triage                        []*generic.TriageAction
+}
+
+// countActivity counts the comments that the user left on an issue or PR
+// during r, including reviewComments, the number of review comments on a PR's
+// diff, the reactions they gave to it and its comments, and the times that
+// someone else @-mentioned them in it. If timeline is set, it also returns the
+// user's triage actions on the issue, from its timeline.
+//
+// Comments are only listed if the issue or PR has any, and only those updated
+// since the start of r. Reactions are only listed for the issue and comments
+// that have any, and only if their number changed since they were cached. If
+// the issue has not been updated since its activity was cached, and it has
+// no comments whose reactions may have changed, nothing is listed at all.
+func countActivity(ctx context.Context, client *github.Client, cache *ActivityCache, org, repo string, issue github.Issue, reviewComments int, timeline bool, username string, r generic.Range) (activityCounts, error) {
+	key := username + " " + issue.GetHTMLURL()
+	prev, ok := cache.lookup(key, issue.GetUpdatedAt(), r.Start)
+	a := prev
+	// Reacting does not update the issue, so the comments are listed again
+	// to check the number of reactions to each of them.
+	if !ok || timeline && !prev.Timeline || issue.GetComments() > 0 || reviewComments > 0 || prev.reactions(issuePath(org, repo, issue)) != issue.Reactions.GetTotalCount() {
+		var err error
+		if a, err = listActivity(ctx, client, org, repo, issue, reviewComments, username, r, prev); err != nil {
+			return activityCounts{}, err
+		}
+		switch {
+		case ok && prev.Timeline:
+			a.Triage, a.Timeline = prev.Triage, true
+		case timeline:
+			if a.Triage, err = triage(ctx, client, org, repo, issue.GetNumber(), username, r); err != nil {
+				return activityCounts{}, err
+			}
+			a.Timeline = true
+		}
+		a.Updated = issue.GetUpdatedAt()
+		a.Since = r.Start
+		cache.store(key, a)
+	}
+	counts := activityCounts{
+		comments: inRange(a.Comments, r),
+		mentions: inRange(a.Mentions, r),
+	}
+	for _, l := range a.Reactions {
+		counts.reactions += inRange(l.Times, r)
+	}
+	for _, action := range a.Triage {
+		if r.Contains(action.Time) {
+			counts.triage = append(counts.triage, action)
+		}
+	}
+	return counts, nil
+}
+
+func inRange(times []time.Time, r generic.Range) int {
+	var n int
+	for _, t := range times {
+		if r.Contains(t) {
+			n++
+		}
+	}
+	return n
+}
+
+// issuePath returns the API path at which the reactions to an issue are
+// listed.
+func issuePath(org, repo string, issue github.Issue) string {
+	return fmt.Sprintf("repos/%v/%v/issues/%d/reactions", org, repo, issue.GetNumber())
+}
+
+// reactions returns the number of reactions to the issue or comment whose
+// reactions are listed at path, when they were cached.
+func (a *activity) reactions(path string) int {
+	if a == nil || a.Reactions[path] == nil {
+		return 0
+	}
+	return a.Reactions[path].Total
+}
+
+// listActivity returns the times at which the user commented on or reacted
+// to an issue or PR, and was mentioned in it, since the start of r.
+// reviewComments is the number of review comments on the PR, so that an
+// empty list is not fetched. The reactions in prev, if it is non-nil, are
+// used for the issue and comments whose number of reactions is unchanged.
+func listActivity(ctx context.Context, client *github.Client, org, repo string, issue github.Issue, reviewComments int, username string, r generic.Range, prev *activity) (*activity, error) {
+	a := &activity{Reactions: make(map[string]*reactions)}
+	// comment records a comment by the user, or someone else's comment that
+	// mentions them.
+	comment := func(user, body string, created time.Time) {
+		switch {
+		case user == username:
+			a.Comments = append(a.Comments, created)
+		case generic.MentionsUser(body, username):
+			a.Mentions = append(a.Mentions, created)
+		}
+	}
+	// react records the user's reactions to the issue or one of its
+	// comments.
+	react := func(counts *github.Reactions, path string) error {
+		total := counts.GetTotalCount()
+		if total == 0 {
+			return nil
+		}
+		if prev.reactions(path) == total {
+			a.Reactions[path] = prev.Reactions[path]
+			return nil
+		}
+		times, err := listReactions(ctx, client, path, username)
+		if err != nil {
+			return err
+		}
+		a.Reactions[path] = &reactions{Total: total, Times: times}
+		return nil
+	}
+
+	if !issue.GetCreatedAt().Before(r.Start) && issue.GetUser().GetLogin() != username {
+		comment(issue.GetUser().GetLogin(), issue.GetBody(), issue.GetCreatedAt())
+	}
+	if err := react(issue.Reactions, issuePath(org, repo, issue)); err != nil {
+		return nil, err
+	}
+	if issue.GetComments() > 0 {
+		opts := &github.IssueListCommentsOptions{
+			Since:       r.Start,
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			page, resp, err := client.Issues.ListComments(ctx, org, repo, issue.GetNumber(), opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range page {
+				comment(c.GetUser().GetLogin(), c.GetBody(), c.GetCreatedAt())
+				if err := react(c.Reactions, fmt.Sprintf("repos/%v/%v/issues/comments/%d/reactions", org, repo, c.GetID())); err != nil {
+					return nil, err
+				}
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	if reviewComments > 0 {
+		opts := &github.PullRequestListCommentsOptions{
+			Since:       r.Start,
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			page, resp, err := client.PullRequests.ListComments(ctx, org, repo, issue.GetNumber(), opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range page {
+				comment(c.GetUser().GetLogin(), c.GetBody(), c.GetCreatedAt())
+				if err := react(c.Reactions, fmt.Sprintf("repos/%v/%v/pulls/comments/%d/reactions", org, repo, c.GetID())); err != nil {
+					return nil, err
+				}
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	return a, nil
+}
+
+// reaction is a reaction to an issue or comment. go-github's Reaction lacks
+// the time at which the reaction was given, so it is decoded by hand.
+type reaction struct {
+	User      *github.User `json:"user"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// listReactions returns the times at which the user gave the reactions
+// listed at path, such as "repos/golang/tools/issues/1/reactions".
+func listReactions(ctx context.Context, client *github.Client, path, username string) ([]time.Time, error) {
+	var times []time.Time
+	for page := 1; page != 0; {
+		req, err := client.NewRequest("GET", fmt.Sprintf("%s?per_page=100&page=%d", path, page), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github.squirrel-girl-preview+json")
+		var reactions []*reaction
+		resp, err := client.Do(ctx, req, &reactions)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range reactions {
+			if r.User.GetLogin() == username {
+				times = append(times, r.CreatedAt)
+			}
+		}
+		page = resp.NextPage
+	}
+	return times, nil
+}
@@ -0,0 +1,161 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+// discussionsQuery searches for discussions with GitHub's GraphQL API, which
+// is the only API that serves them. Only the first 100 comments of each
+// discussion, and the first 100 replies to each comment, are fetched.
+const discussionsQuery = `query($q: String!, $first: Int!, $after: String) {
+  search(type: DISCUSSION, query: $q, first: $first, after: $after) {
+    pageInfo { hasNextPage endCursor }
+    nodes {
+      ... on Discussion {
+        number
+        title
+        url
+        createdAt
+        author { login }
+        category { name }
+        repository { nameWithOwner }
+        answer { author { login } createdAt }
+        comments(first: 100) {
+          nodes {
+            author { login }
+            createdAt
+            replies(first: 100) { nodes { author { login } createdAt } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLAuthor struct {
+	Login string `json:"login"`
+}
+
+type graphQLComment struct {
+	Author    *graphQLAuthor `json:"author"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Replies   struct {
+		Nodes []*graphQLComment `json:"nodes"`
+	} `json:"replies"`
+}
+
+// by reports whether the user wrote the comment during r. Comments by deleted
+// users have no author.
+func (c *graphQLComment) by(username string, r generic.Range) bool {
+	return c != nil && c.Author != nil && c.Author.Login == username && r.Contains(c.CreatedAt)
+}
+
+type graphQLDiscussion struct {
+	graphQLComment
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Category struct {
+		Name string `json:"name"`
+	} `json:"category"`
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+	Answer   *graphQLComment `json:"answer"`
+	Comments struct {
+		Nodes []*graphQLComment `json:"nodes"`
+	} `json:"comments"`
+}
+
+// Discussions returns the GitHub Discussions that the user started,
+// commented on, or answered during r.
+func Discussions(ctx context.Context, client *github.Client, username string, r generic.Range) ([]*generic.Discussion, error) {
+	q := fmt.Sprintf("involves:%v updated:%s..%s", username, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339))
+	var discussions []*generic.Discussion
+	var after *string
+	for {
+		body := map[string]interface{}{
+			"query": discussionsQuery,
+			"variables": map[string]interface{}{
+				"q":     q,
+				"first": 100,
+				"after": after,
+			},
+		}
+		req, err := client.NewRequest("POST", "graphql", body)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Data struct {
+				Search struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []*graphQLDiscussion `json:"nodes"`
+				} `json:"search"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if _, err := client.Do(ctx, req, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("searching discussions: %s", resp.Errors[0].Message)
+		}
+		for _, node := range resp.Data.Search.Nodes {
+			if d := toGenericDiscussion(node, username, r); d != nil {
+				discussions = append(discussions, d)
+			}
+		}
+		if !resp.Data.Search.PageInfo.HasNextPage {
+			break
+		}
+		after = &resp.Data.Search.PageInfo.EndCursor
+	}
+	sort.Slice(discussions, func(i, j int) bool {
+		return discussions[i].Link < discussions[j].Link
+	})
+	return discussions, nil
+}
+
+// toGenericDiscussion returns the user's participation in the discussion
+// during r, or nil if they did not participate.
+func toGenericDiscussion(node *graphQLDiscussion, username string, r generic.Range) *generic.Discussion {
+	d := &generic.Discussion{
+		Number:   node.Number,
+		Link:     node.URL,
+		Repo:     node.Repository.NameWithOwner,
+		Title:    node.Title,
+		Category: node.Category.Name,
+		Created:  node.CreatedAt,
+		Started:  node.graphQLComment.by(username, r),
+		Answered: node.Answer.by(username, r),
+	}
+	if node.Author != nil {
+		d.Author = node.Author.Login
+	}
+	for _, c := range node.Comments.Nodes {
+		if c.by(username, r) {
+			d.Comments++
+		}
+		for _, reply := range c.Replies.Nodes {
+			if reply.by(username, r) {
+				d.Comments++
+			}
+		}
+	}
+	if !d.Started && !d.Answered && d.Comments == 0 {
+		return nil
+	}
+	return d
+}
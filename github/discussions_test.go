@@ -0,0 +1,134 @@
+package github_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+	"github.com/stamblerre/work-stats/github"
+	"github.com/stamblerre/work-stats/internal/githubtest"
+)
+
+func TestDiscussions(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	server.AddDiscussion(
+		// A discussion started by alice, who replied to a comment.
+		&githubtest.Discussion{
+			Owner:    "golang",
+			Repo:     "go",
+			Number:   50,
+			Title:    "generics: iterators",
+			User:     "alice",
+			Category: "Ideas",
+			Created:  day(2),
+			Comments: []*githubtest.DiscussionComment{{
+				User:    "bob",
+				Created: day(3),
+				Replies: []*githubtest.Comment{
+					{User: "alice", Created: day(4)},
+					{User: "bob", Created: day(5)},
+				},
+			}},
+		},
+		// A question that alice answered.
+		&githubtest.Discussion{
+			Owner:    "stamblerre",
+			Repo:     "work-stats",
+			Number:   1,
+			Title:    "How do I use snippets?",
+			User:     "bob",
+			Category: "Q&A",
+			Created:  day(6),
+			Comments: []*githubtest.DiscussionComment{
+				{User: "carol", Created: day(6)},
+				{User: "alice", Created: day(7), Answer: true},
+			},
+		},
+		// A discussion that alice was only mentioned in.
+		&githubtest.Discussion{
+			Owner:    "stamblerre",
+			Repo:     "work-stats",
+			Number:   2,
+			Title:    "Roadmap",
+			User:     "bob",
+			Body:     "@alice, any thoughts?",
+			Category: "General",
+			Created:  day(8),
+		},
+		// A discussion that alice commented on before March.
+		&githubtest.Discussion{
+			Owner:    "stamblerre",
+			Repo:     "work-stats",
+			Number:   3,
+			Title:    "Old news",
+			User:     "bob",
+			Category: "General",
+			Created:  start.AddDate(0, -1, 0),
+			Comments: []*githubtest.DiscussionComment{
+				{User: "alice", Created: start.AddDate(0, -1, 0)},
+				{User: "bob", Created: day(9)},
+			},
+		},
+	)
+	got, err := github.Discussions(context.Background(), server.Client(), "alice", generic.Range{Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*generic.Discussion{
+		{
+			Number:   50,
+			Link:     "https://github.com/golang/go/discussions/50",
+			Repo:     "golang/go",
+			Title:    "generics: iterators",
+			Category: "Ideas",
+			Author:   "alice",
+			Created:  day(2),
+			Started:  true,
+			Comments: 1,
+		},
+		{
+			Number:   1,
+			Link:     "https://github.com/stamblerre/work-stats/discussions/1",
+			Repo:     "stamblerre/work-stats",
+			Title:    "How do I use snippets?",
+			Category: "Q&A",
+			Author:   "bob",
+			Created:  day(6),
+			Comments: 1,
+			Answered: true,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected discussions: %s", diff)
+	}
+}
+
+func TestDiscussionsPagination(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	const n = 250
+	for i := 1; i <= n; i++ {
+		server.AddDiscussion(&githubtest.Discussion{
+			Owner:   "stamblerre",
+			Repo:    "work-stats",
+			Number:  i,
+			Title:   fmt.Sprintf("discussion %d", i),
+			User:    "alice",
+			Created: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	got, err := github.Discussions(context.Background(), server.Client(), "alice", generic.Range{Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n {
+		t.Errorf("got %d discussions, want %d", len(got), n)
+	}
+	if got, want := server.Requests("graphql"), 3; got != want {
+		t.Errorf("got %d GraphQL requests, want %d", got, want)
+	}
+}
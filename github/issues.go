@@ -14,29 +14,140 @@ import (
 	"golang.org/x/oauth2"
 )
 
-func IssuesAndPRs(ctx context.Context, username string, start, end time.Time) (authored, reviewed []*generic.Changelist, issues []*generic.Issue, err error) {
+// NewClient returns a GitHub client authenticated with the token in the
+// GITHUB_TOKEN environment variable.
+func NewClient(ctx context.Context) (*github.Client, error) {
 	token := os.Getenv("GITHUB_TOKEN")
 	if token == "" {
-		return nil, nil, nil, fmt.Errorf("GITHUB_TOKEN environment variable is not configured")
+		return nil, fmt.Errorf("GITHUB_TOKEN environment variable is not configured")
 	}
 	ts := oauth2.StaticTokenSource(&oauth2.Token{
 		AccessToken: token,
 	})
 	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	return github.NewClient(tc), nil
+}
 
+// IssuesAndPRs returns the PRs that the user authored and reviewed, and the
+// issues they were involved in, during r, outside of the golang
+// organization's issues. The user's comments, reactions, mentions, and triage
+// are read from cache if it is non-nil, and fetched and stored there
+// otherwise.
+//
+// Issues and PRs are found by separate searches, so that the issue search is
+// restricted to is:issue as it always was, and PRs are searched on purpose.
+func IssuesAndPRs(ctx context.Context, client *github.Client, cache *ActivityCache, username string, r generic.Range) (authored, reviewed []*generic.Changelist, issues []*generic.Issue, err error) {
 	issuesMap := make(map[string]*generic.Issue)
 	authoredMap := make(map[string]*generic.Changelist)
 	reviewedMap := make(map[string]*generic.Changelist)
-	seen := make(map[string]struct{})
 
+	if err := search(ctx, client, "is:issue involves:"+username, r, func(issue github.Issue, org, repo string) error {
+		// golang issues are tracked via the golang package.
+		if org == "golang" {
+			return nil
+		}
+		counts, err := countActivity(ctx, client, cache, org, repo, issue, 0, true, username, r)
+		if err != nil {
+			return err
+		}
+		gi := GitHubToGenericIssue(issue, org, repo, counts.comments)
+		gi.Reactions = counts.reactions
+		gi.Mentions = counts.mentions
+		gi.Triage = counts.triage
+		issuesMap[issue.GetHTMLURL()] = gi
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// PRs are reported as authored or reviewed, with their sizes.
+	if err := search(ctx, client, "is:pr involves:"+username, r, func(issue github.Issue, org, repo string) error {
+		openedBy := issue.GetUser().GetLogin()
+		// golang PRs are mirrored to Gerrit, where their CLs are reported,
+		// so they are only collected from the search results, for
+		// generic.DedupChangelists to link them to their CLs.
+		if org == "golang" {
+			gc := GitHubToGenericChangelist(issue, org, repo, generic.Unknown)
+			if openedBy == username {
+				authoredMap[issue.GetHTMLURL()] = gc
+			} else {
+				reviewedMap[issue.GetHTMLURL()] = gc
+			}
+			return nil
+		}
+		closed := issue.GetClosedBy() != nil || !issue.GetClosedAt().Equal(time.Time{})
+		// The PR records whether it was merged, as well as its size.
+		pr, _, err := client.PullRequests.Get(ctx, org, repo, issue.GetNumber())
+		if err != nil {
+			return err
+		}
+		status := generic.Unknown
+		if closed {
+			// Ignore PRs that have been closed without being merged.
+			if !pr.GetMerged() {
+				return nil
+			}
+			status = generic.Merged
+		}
+		gc := GitHubToGenericChangelist(issue, org, repo, status)
+		firstReview, err := firstReviewTime(ctx, client, org, repo, issue.GetNumber(), openedBy)
+		if err != nil {
+			return err
+		}
+		gc.FirstReviewAt = firstReview
+		gc.Patchsets = pr.GetCommits()
+		gc.FilesChanged = pr.GetChangedFiles()
+		gc.LinesAdded = pr.GetAdditions()
+		gc.LinesDeleted = pr.GetDeletions()
+		counts, err := countActivity(ctx, client, cache, org, repo, issue, pr.GetReviewComments(), false, username, r)
+		if err != nil {
+			return err
+		}
+		gc.UserComments = counts.comments
+		if openedBy == username {
+			authoredMap[issue.GetHTMLURL()] = gc
+		} else {
+			reviewedMap[issue.GetHTMLURL()] = gc
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, i := range issuesMap {
+		issues = append(issues, i)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Link < issues[j].Link
+	})
+	for _, pr := range authoredMap {
+		authored = append(authored, pr)
+	}
+	for _, pr := range reviewedMap {
+		reviewed = append(reviewed, pr)
+	}
+	sort.Slice(authored, func(i, j int) bool {
+		return authored[i].Link < authored[j].Link
+	})
+	sort.Slice(reviewed, func(i, j int) bool {
+		return reviewed[i].Link < reviewed[j].Link
+	})
+	return authored, reviewed, issues, nil
+}
+
+// search calls f with each of the issues and PRs that match query and were
+// updated during r, and the organization and repository they are in. The
+// search API returns at most 1000 results, so once they have been seen, the
+// search is repeated from the time of the last update among them, until that
+// time no longer advances.
+func search(ctx context.Context, client *github.Client, query string, r generic.Range, f func(issue github.Issue, org, repo string) error) error {
+	seen := make(map[string]struct{})
 	var mostRecentIssue time.Time
-	last := start
-outer:
+	last := r.Start
 	for {
 		var current int
 		for i := 1; i < 11; i++ {
-			result, _, err := client.Search.Issues(ctx, fmt.Sprintf("is:issue involves:%v updated:%s..%s", username, last.Format(time.RFC3339), end.Format(time.RFC3339)), &github.SearchOptions{
+			result, _, err := client.Search.Issues(ctx, fmt.Sprintf("%s updated:%s..%s", query, last.Format(time.RFC3339), r.End.Format(time.RFC3339)), &github.SearchOptions{
 				ListOptions: github.ListOptions{
 					Page:    i,
 					PerPage: 100,
@@ -45,96 +156,102 @@ outer:
 				Order: "asc",
 			})
 			if err != nil {
-				return nil, nil, nil, err
+				return err
 			}
 			for _, issue := range result.Issues {
+				if updated := issue.GetUpdatedAt(); updated.After(mostRecentIssue) {
+					mostRecentIssue = updated
+				}
 				if _, ok := seen[issue.GetHTMLURL()]; ok {
 					continue
 				}
 				seen[issue.GetHTMLURL()] = struct{}{}
-				mostRecentIssue = *issue.UpdatedAt
-				trimmed := strings.TrimPrefix(issue.GetRepositoryURL(), "https://api.github.com/repos/")
-				split := strings.SplitN(trimmed, "/", 2)
-				org, repo := split[0], split[1]
-				// golang issues are tracker via the golang package.
-				if org == "golang" {
+				// The search index can be out of sync with the issues, so
+				// skip those that were not updated during r before
+				// fetching anything else about them.
+				if issue.GetUpdatedAt().Before(r.Start) {
 					continue
 				}
-				// Only mark issues as opened if the user opened them since the specified date.
-				openedBy := issue.GetUser().GetLogin()
-				closed := issue.GetClosedBy() != nil || !issue.GetClosedAt().Equal(time.Time{})
-				if issue.IsPullRequest() {
-					status := generic.Unknown
-					if closed {
-						// Check if the PR has been merged. (It may have been
-						// closed without being merged.)
-						merged, _, err := client.PullRequests.IsMerged(ctx, org, repo, issue.GetNumber())
-						if err != nil {
-							return nil, nil, nil, err
-						}
-						// Ignore issues that have been closed without being
-						// merged. This will ignore merged PRs that are
-						// mirrored from Gerrit because those are closed, even
-						// though the CL has been merged.
-						if !merged {
-							continue
-						}
-						status = generic.Merged
-					}
-					gc := GitHubToGenericChangelist(issue, org, repo, status)
-					if openedBy == username {
-						authoredMap[issue.GetHTMLURL()] = gc
-					} else {
-						reviewedMap[issue.GetHTMLURL()] = gc
-					}
-					continue
-				}
-				comments, _, err := client.Issues.ListComments(ctx, org, repo, issue.GetNumber(), nil)
-				if err != nil {
-					return nil, nil, nil, err
-				}
-				var numComments int
-				for _, c := range comments {
-					if c.GetUser().GetLogin() != username {
-						continue
-					}
-					if !inScope(c.GetCreatedAt(), start, end) {
-						continue
-					}
-					numComments++
+				trimmed := strings.TrimPrefix(issue.GetRepositoryURL(), "https://api.github.com/repos/")
+				split := strings.SplitN(trimmed, "/", 2)
+				if err := f(issue, split[0], split[1]); err != nil {
+					return err
 				}
-				issuesMap[issue.GetHTMLURL()] = GitHubToGenericIssue(issue, org, repo, numComments)
 			}
 			current += len(result.Issues)
 			if current >= result.GetTotal() {
-				break outer
+				return nil
 			}
 		}
+		// Searching again from the same time would return the same
+		// results.
+		if !mostRecentIssue.After(last) {
+			return nil
+		}
 		last = mostRecentIssue
 	}
-	for _, i := range issuesMap {
-		issues = append(issues, i)
-	}
-	sort.Slice(issues, func(i, j int) bool {
-		return issues[i].Link < issues[j].Link
-	})
-	for _, pr := range authoredMap {
-		authored = append(authored, pr)
-	}
-	for _, pr := range reviewedMap {
-		reviewed = append(reviewed, pr)
+}
+
+// firstReviewTime returns the time of the first review of a PR by someone
+// other than its author, or the zero time if there is none.
+func firstReviewTime(ctx context.Context, client *github.Client, org, repo string, number int, author string) (time.Time, error) {
+	var first time.Time
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(ctx, org, repo, number, opts)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, r := range reviews {
+			if r.GetUser().GetLogin() == author {
+				continue
+			}
+			if t := r.GetSubmittedAt(); !t.IsZero() && (first.IsZero() || t.Before(first)) {
+				first = t
+			}
+		}
+		if resp.NextPage == 0 {
+			return first, nil
+		}
+		opts.Page = resp.NextPage
 	}
-	sort.Slice(authored, func(i, j int) bool {
-		return authored[i].Link < authored[j].Link
-	})
-	sort.Slice(reviewed, func(i, j int) bool {
-		return reviewed[i].Link < reviewed[j].Link
-	})
-	return authored, reviewed, issues, nil
 }
 
-func inScope(t, start, end time.Time) bool {
-	return t.After(start) && t.Before(end)
+// triage returns the triage actions, such as labeling or assigning, that the
+// user took on an issue since the start of r, from the issue's timeline.
+func triage(ctx context.Context, client *github.Client, org, repo string, number int, username string, r generic.Range) ([]*generic.TriageAction, error) {
+	var actions []*generic.TriageAction
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		events, resp, err := client.Issues.ListIssueTimeline(ctx, org, repo, number, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if e.GetActor().GetLogin() != username {
+				continue
+			}
+			if e.GetCreatedAt().Before(r.Start) {
+				continue
+			}
+			var detail string
+			switch {
+			case e.Label != nil:
+				detail = e.Label.GetName()
+			case e.Milestone != nil:
+				detail = e.Milestone.GetTitle()
+			case e.Assignee != nil:
+				detail = e.Assignee.GetLogin()
+			}
+			if action, ok := generic.ToTriageAction(e.GetEvent(), detail, e.GetCreatedAt()); ok {
+				actions = append(actions, action)
+			}
+		}
+		if resp.NextPage == 0 {
+			return actions, nil
+		}
+		opts.Page = resp.NextPage
+	}
 }
 
 func WasTransferred(ctx context.Context, client *github.Client, owner, repo string, number int32) (bool, error) {
@@ -168,13 +285,14 @@ func GitHubToGenericIssue(issue github.Issue, org, repo string, numComments int)
 
 func GitHubToGenericChangelist(pr github.Issue, org, repo string, status generic.ChangelistStatus) *generic.Changelist {
 	return &generic.Changelist{
-		Repo:     fmt.Sprintf("%s/%s", org, repo),
-		Subject:  pr.GetTitle(),
-		Message:  pr.GetBody(),
-		Link:     pr.GetHTMLURL(),
-		Author:   pr.GetUser().GetLogin(),
-		Number:   pr.GetNumber(),
-		Status:   status,
-		MergedAt: pr.GetClosedAt(),
+		Repo:      fmt.Sprintf("%s/%s", org, repo),
+		Subject:   pr.GetTitle(),
+		Message:   pr.GetBody(),
+		Link:      pr.GetHTMLURL(),
+		Author:    pr.GetUser().GetLogin(),
+		Number:    pr.GetNumber(),
+		Status:    status,
+		CreatedAt: pr.GetCreatedAt(),
+		MergedAt:  pr.GetClosedAt(),
 	}
 }
@@ -0,0 +1,660 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	gh "github.com/google/go-github/v28/github"
+	"github.com/stamblerre/work-stats/generic"
+	"github.com/stamblerre/work-stats/github"
+	"github.com/stamblerre/work-stats/internal/githubtest"
+)
+
+var (
+	start = time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end   = time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// day returns noon on the given day in March 2022.
+func day(d int) time.Time {
+	return time.Date(2022, time.March, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestIssuesAndPRs(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	server.AddIssue(
+		// An issue opened by alice with comments and reactions from
+		// several people, some of which are out of range.
+		&githubtest.Issue{
+			Owner:     "stamblerre",
+			Repo:      "work-stats",
+			Number:    1,
+			Title:     "generic: support categories",
+			User:      "alice",
+			Created:   day(1),
+			Milestone: "v1",
+			Comments: []*githubtest.Comment{
+				{User: "alice", Created: day(1).AddDate(0, -1, 0)},
+				{User: "alice", Created: day(2)},
+				{
+					User:    "bob",
+					Created: day(3),
+					Body:    "@alice, what about labels?",
+					Reactions: []*githubtest.Reaction{
+						{User: "alice", Created: day(3).Add(time.Hour)},
+						{User: "bob", Created: day(3).Add(time.Hour), Content: "heart"},
+					},
+				},
+				{User: "alice", Created: day(4)},
+			},
+			Reactions: []*githubtest.Reaction{
+				{User: "alice", Created: day(1).AddDate(0, -1, 0)},
+				{User: "bob", Created: day(2), Content: "rocket"},
+			},
+		},
+		// An issue closed by bob that alice commented on and triaged.
+		&githubtest.Issue{
+			Owner:    "stamblerre",
+			Repo:     "sheets",
+			Number:   2,
+			Title:    "crash on empty sheet",
+			User:     "bob",
+			Created:  day(5),
+			ClosedAt: day(7),
+			ClosedBy: "bob",
+			Comments: []*githubtest.Comment{
+				{User: "alice", Created: day(6)},
+			},
+			Events: []*githubtest.Event{
+				{Type: "labeled", Actor: "alice", Created: day(6), Label: "WaitingForInfo"},
+				{Type: "assigned", Actor: "alice", Created: day(6), Assignee: "bob"},
+				{Type: "labeled", Actor: "bob", Created: day(6), Label: "bug"},
+				{Type: "closed", Actor: "bob", Created: day(7)},
+			},
+		},
+		// A merged PR authored by alice.
+		&githubtest.Issue{
+			Owner:        "stamblerre",
+			Repo:         "work-stats",
+			Number:       3,
+			Title:        "github: add tests",
+			User:         "alice",
+			Created:      day(8),
+			ClosedAt:     day(9),
+			PullRequest:  true,
+			Merged:       true,
+			Commits:      3,
+			ChangedFiles: 2,
+			Additions:    120,
+			Deletions:    15,
+			Reviews: []*githubtest.Review{
+				{User: "alice", Submitted: day(8).Add(time.Hour), State: "COMMENTED"},
+				{User: "bob", Submitted: day(8).Add(3 * time.Hour), State: "APPROVED"},
+			},
+		},
+		// An open PR authored by alice.
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "work-stats",
+			Number:      4,
+			Title:       "snippets: templates",
+			User:        "alice",
+			Created:     day(10),
+			PullRequest: true,
+		},
+		// A merged PR authored by bob and reviewed by alice.
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "sheets",
+			Number:      5,
+			Title:       "fix resizing",
+			User:        "bob",
+			Created:     day(11),
+			ClosedAt:    day(12),
+			PullRequest: true,
+			Merged:      true,
+			Comments: []*githubtest.Comment{
+				{User: "alice", Created: day(11)},
+			},
+			Reviews: []*githubtest.Review{
+				{User: "alice", Submitted: day(11).Add(time.Hour), State: "APPROVED"},
+			},
+			ReviewComments: []*githubtest.Comment{
+				{User: "alice", Created: day(11).Add(time.Hour), Body: "nit: typo"},
+				{User: "bob", Created: day(11).Add(2 * time.Hour), Body: "Done."},
+				{User: "alice", Created: day(11).Add(3 * time.Hour), Body: "Thanks."},
+			},
+		},
+		// A closed, unmerged PR, such as a PR mirrored to Gerrit.
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "sheets",
+			Number:      6,
+			Title:       "mirrored change",
+			User:        "alice",
+			Created:     day(13),
+			ClosedAt:    day(14),
+			PullRequest: true,
+		},
+		// An issue that alice was only mentioned in.
+		&githubtest.Issue{
+			Owner:   "stamblerre",
+			Repo:    "sheets",
+			Number:  10,
+			Title:   "formulas",
+			User:    "carol",
+			Created: day(16),
+			Body:    "cc @alice",
+			Comments: []*githubtest.Comment{
+				{User: "bob", Created: day(17), Body: "Email alice@example.com."},
+			},
+		},
+		// golang issues are reported by the golang package.
+		&githubtest.Issue{
+			Owner:   "golang",
+			Repo:    "go",
+			Number:  7,
+			Title:   "cmd/go: broken",
+			User:    "alice",
+			Created: day(15),
+		},
+		// A golang PR, which gerritbot closed after importing it into
+		// Gerrit. Its status is only known from Gerrit.
+		&githubtest.Issue{
+			Owner:       "golang",
+			Repo:        "tools",
+			Number:      9,
+			Title:       "gopls: fix typo",
+			Body:        "Fixes a typo in the docs.",
+			User:        "alice",
+			Created:     day(17),
+			ClosedAt:    day(18),
+			ClosedBy:    "gopherbot",
+			PullRequest: true,
+		},
+		// An issue that doesn't involve alice.
+		&githubtest.Issue{
+			Owner:   "stamblerre",
+			Repo:    "sheets",
+			Number:  8,
+			Title:   "unrelated",
+			User:    "bob",
+			Created: day(16),
+		},
+	)
+
+	authored, reviewed, issues, err := github.IssuesAndPRs(context.Background(), server.Client(), nil, "alice", generic.Range{Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantAuthored := []*generic.Changelist{
+		{
+			Number:    9,
+			Link:      "https://github.com/golang/tools/pull/9",
+			Subject:   "gopls: fix typo",
+			Message:   "Fixes a typo in the docs.",
+			Author:    "alice",
+			Repo:      "golang/tools",
+			Status:    generic.Unknown,
+			CreatedAt: day(17),
+			MergedAt:  day(18),
+		},
+		{
+			Number:        3,
+			Link:          "https://github.com/stamblerre/work-stats/pull/3",
+			Subject:       "github: add tests",
+			Author:        "alice",
+			Repo:          "stamblerre/work-stats",
+			Status:        generic.Merged,
+			CreatedAt:     day(8),
+			FirstReviewAt: day(8).Add(3 * time.Hour),
+			MergedAt:      day(9),
+			Patchsets:     3,
+			FilesChanged:  2,
+			LinesAdded:    120,
+			LinesDeleted:  15,
+		},
+		{
+			Number:    4,
+			Link:      "https://github.com/stamblerre/work-stats/pull/4",
+			Subject:   "snippets: templates",
+			Author:    "alice",
+			Repo:      "stamblerre/work-stats",
+			Status:    generic.Unknown,
+			CreatedAt: day(10),
+		},
+	}
+	if diff := cmp.Diff(wantAuthored, authored); diff != "" {
+		t.Errorf("unexpected authored PRs: %s", diff)
+	}
+	wantReviewed := []*generic.Changelist{{
+		Number:        5,
+		Link:          "https://github.com/stamblerre/sheets/pull/5",
+		Subject:       "fix resizing",
+		Author:        "bob",
+		Repo:          "stamblerre/sheets",
+		Status:        generic.Merged,
+		CreatedAt:     day(11),
+		FirstReviewAt: day(11).Add(time.Hour),
+		MergedAt:      day(12),
+		UserComments:  3,
+	}}
+	if diff := cmp.Diff(wantReviewed, reviewed); diff != "" {
+		t.Errorf("unexpected reviewed PRs: %s", diff)
+	}
+	// Each PR is fetched once, which also tells whether it was merged, and
+	// nothing but the search results is fetched for golang PRs.
+	if got, want := server.Requests("pull"), 4; got != want {
+		t.Errorf("got %d requests for PRs, want %d", got, want)
+	}
+	if got, want := server.Requests("reviews"), 3; got != want {
+		t.Errorf("got %d requests for reviews, want %d", got, want)
+	}
+	if got := server.Requests("merged"); got != 0 {
+		t.Errorf("got %d requests for merge status, want none", got)
+	}
+	wantIssues := []*generic.Issue{
+		{
+			Number:     10,
+			Link:       "https://github.com/stamblerre/sheets/issues/10",
+			Repo:       "stamblerre/sheets",
+			Title:      "formulas",
+			OpenedBy:   "carol",
+			DateOpened: day(16),
+			Mentions:   1,
+		},
+		{
+			Number:     2,
+			Link:       "https://github.com/stamblerre/sheets/issues/2",
+			Repo:       "stamblerre/sheets",
+			Title:      "crash on empty sheet",
+			OpenedBy:   "bob",
+			ClosedBy:   "bob",
+			DateOpened: day(5),
+			DateClosed: day(7),
+			Comments:   1,
+			Triage: []*generic.TriageAction{
+				{Kind: generic.Triaged, Detail: "WaitingForInfo", Time: day(6)},
+				{Kind: generic.Assigned, Detail: "bob", Time: day(6)},
+			},
+		},
+		{
+			Number:     1,
+			Link:       "https://github.com/stamblerre/work-stats/issues/1",
+			Repo:       "stamblerre/work-stats",
+			Title:      "generic: support categories",
+			OpenedBy:   "alice",
+			DateOpened: day(1),
+			Comments:   2,
+			Reactions:  1,
+			Mentions:   1,
+			Milestone:  "v1",
+		},
+	}
+	if diff := cmp.Diff(wantIssues, issues); diff != "" {
+		t.Errorf("unexpected issues: %s", diff)
+	}
+}
+
+func TestIssuesAndPRsPagination(t *testing.T) {
+	for _, n := range []int{0, 1, 100, 101, 1000, 1001, 2500} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			server := githubtest.NewServer()
+			defer server.Close()
+			for i := 1; i <= n; i++ {
+				server.AddIssue(&githubtest.Issue{
+					Owner:  "stamblerre",
+					Repo:   "work-stats",
+					Number: i,
+					Title:  fmt.Sprintf("issue %d", i),
+					User:   "alice",
+					// Several issues share each update time, so that
+					// follow-up searches overlap with earlier results.
+					Created: start.Add(time.Duration(i/3) * time.Minute),
+				})
+			}
+			_, _, issues, err := github.IssuesAndPRs(context.Background(), server.Client(), nil, "alice", generic.Range{Start: start, End: end})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != n {
+				t.Errorf("got %v issues, want %v", len(issues), n)
+			}
+			seen := make(map[string]bool)
+			for _, issue := range issues {
+				if seen[issue.Link] {
+					t.Errorf("duplicate issue %s", issue.Link)
+				}
+				seen[issue.Link] = true
+			}
+		})
+	}
+}
+
+func TestIssuesAndPRsComments(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	// Alice and bob take turns commenting every hour, from a week before
+	// March until 400 comments have been made.
+	var comments []*githubtest.Comment
+	for i := 0; i < 400; i++ {
+		user := "alice"
+		if i%2 == 1 {
+			user = "bob"
+		}
+		comments = append(comments, &githubtest.Comment{User: user, Created: start.AddDate(0, 0, -7).Add(time.Duration(i) * time.Hour)})
+	}
+	chatty := &githubtest.Issue{
+		Owner:    "stamblerre",
+		Repo:     "work-stats",
+		Number:   1,
+		Title:    "a long discussion",
+		User:     "alice",
+		Created:  start.AddDate(0, 0, -7),
+		Comments: comments,
+	}
+	server.AddIssue(
+		chatty,
+		// Issues without comments are not listed.
+		&githubtest.Issue{
+			Owner:   "stamblerre",
+			Repo:    "work-stats",
+			Number:  2,
+			Title:   "a quiet issue",
+			User:    "alice",
+			Created: day(1),
+		},
+	)
+	path := filepath.Join(t.TempDir(), "cache.json")
+	cache, err := github.OpenActivityCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect := func() int {
+		t.Helper()
+		_, _, issues, err := github.IssuesAndPRs(context.Background(), server.Client(), cache, "alice", generic.Range{Start: start, End: end})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, issue := range issues {
+			if issue.Number == 1 {
+				return issue.Comments
+			}
+		}
+		t.Fatal("issue 1 was not found")
+		return 0
+	}
+
+	// 168 of the comments were made before March, so the 232 since then are
+	// listed in 3 pages.
+	if got, want := collect(), 116; got != want {
+		t.Errorf("got %d comments, want %d", got, want)
+	}
+	if got, want := server.Requests("comments"), 3; got != want {
+		t.Errorf("got %d requests for comments, want %d", got, want)
+	}
+
+	// The cache is saved and reopened. Reacting to a comment does not update
+	// the issue, so its comments are listed again to check their reactions.
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if cache, err = github.OpenActivityCache(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := collect(), 116; got != want {
+		t.Errorf("got %d comments from the cache, want %d", got, want)
+	}
+	if got, want := server.Requests("comments"), 6; got != want {
+		t.Errorf("got %d requests for comments, want %d", got, want)
+	}
+	chatty.Comments = append(chatty.Comments, &githubtest.Comment{User: "alice", Created: day(20)})
+	if got, want := collect(), 117; got != want {
+		t.Errorf("got %d comments after an update, want %d", got, want)
+	}
+	if got, want := server.Requests("comments"), 9; got != want {
+		t.Errorf("got %d requests for comments, want %d", got, want)
+	}
+}
+
+func TestIssuesAndPRsStalePages(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	// More issues than one search returns, all of which the search index
+	// says were updated in March, although they were last updated in
+	// February.
+	for i := 1; i <= 1001; i++ {
+		server.AddIssue(&githubtest.Issue{
+			Owner:   "stamblerre",
+			Repo:    "work-stats",
+			Number:  i,
+			Title:   fmt.Sprintf("issue %d", i),
+			User:    "alice",
+			Created: start.AddDate(0, 0, -7),
+			Indexed: day(2),
+		})
+	}
+	_, _, issues, err := github.IssuesAndPRs(context.Background(), server.Client(), nil, "alice", generic.Range{Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("got %d issues, want none", len(issues))
+	}
+	// The issue search stops after a sweep of 10 pages, since the last
+	// update among them is not after the start of the range. One more
+	// search finds no PRs.
+	if got, want := server.Requests("search"), 11; got != want {
+		t.Errorf("got %d searches, want %d", got, want)
+	}
+}
+
+func TestIssuesAndPRsTriageCache(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	issue := &githubtest.Issue{
+		Owner:   "stamblerre",
+		Repo:    "work-stats",
+		Number:  1,
+		Title:   "crash",
+		User:    "bob",
+		Created: day(1),
+		Body:    "cc @alice",
+		Events: []*githubtest.Event{
+			{Type: "labeled", Actor: "alice", Created: day(2), Label: "NeedsInvestigation"},
+		},
+	}
+	server.AddIssue(issue)
+	cache, err := github.OpenActivityCache(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect := func() []*generic.TriageAction {
+		t.Helper()
+		_, _, issues, err := github.IssuesAndPRs(context.Background(), server.Client(), cache, "alice", generic.Range{Start: start, End: end})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 1 {
+			t.Fatalf("got %d issues, want 1", len(issues))
+		}
+		return issues[0].Triage
+	}
+	want := []*generic.TriageAction{{Kind: generic.Triaged, Detail: "NeedsInvestigation", Time: day(2)}}
+
+	// The timeline is only listed again once the issue is updated.
+	for i := 0; i < 2; i++ {
+		if diff := cmp.Diff(want, collect()); diff != "" {
+			t.Errorf("run %d: unexpected triage (-want +got):\n%s", i+1, diff)
+		}
+		if got, want := server.Requests("timeline"), 1; got != want {
+			t.Errorf("run %d: got %d requests for the timeline, want %d", i+1, got, want)
+		}
+	}
+	issue.Events = append(issue.Events, &githubtest.Event{Type: "assigned", Actor: "alice", Created: day(20), Assignee: "bob"})
+	want = append(want, &generic.TriageAction{Kind: generic.Assigned, Detail: "bob", Time: day(20)})
+	if diff := cmp.Diff(want, collect()); diff != "" {
+		t.Errorf("after an update: unexpected triage (-want +got):\n%s", diff)
+	}
+	if got, want := server.Requests("timeline"), 2; got != want {
+		t.Errorf("after an update: got %d requests for the timeline, want %d", got, want)
+	}
+}
+
+func TestIssuesAndPRsReactionsCache(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	comment := &githubtest.Comment{User: "bob", Created: day(2), Body: "cc @alice"}
+	issue := &githubtest.Issue{
+		Owner:    "stamblerre",
+		Repo:     "work-stats",
+		Number:   1,
+		Title:    "crash",
+		User:     "bob",
+		Created:  day(1),
+		Updated:  day(2),
+		Comments: []*githubtest.Comment{comment},
+	}
+	server.AddIssue(issue)
+	cache, err := github.OpenActivityCache(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect := func() int {
+		t.Helper()
+		_, _, issues, err := github.IssuesAndPRs(context.Background(), server.Client(), cache, "alice", generic.Range{Start: start, End: end})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 1 {
+			t.Fatalf("got %d issues, want 1", len(issues))
+		}
+		return issues[0].Reactions
+	}
+	if got, want := collect(), 0; got != want {
+		t.Errorf("got %d reactions, want %d", got, want)
+	}
+
+	// Reacting does not update the issue, but the reactions are still
+	// counted, and they are only listed again once their number changes.
+	issue.Reactions = append(issue.Reactions, &githubtest.Reaction{User: "alice", Created: day(3)})
+	comment.Reactions = append(comment.Reactions, &githubtest.Reaction{User: "alice", Created: day(3)})
+	for i := 0; i < 2; i++ {
+		if got, want := collect(), 2; got != want {
+			t.Errorf("run %d: got %d reactions, want %d", i+1, got, want)
+		}
+		if got, want := server.Requests("reactions"), 2; got != want {
+			t.Errorf("run %d: got %d requests for reactions, want %d", i+1, got, want)
+		}
+	}
+}
+
+func TestIssuesAndPRsNotUpdated(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	// The search index says that these were updated in March, but they
+	// were last updated in February.
+	server.AddIssue(
+		&githubtest.Issue{
+			Owner:    "stamblerre",
+			Repo:     "work-stats",
+			Number:   1,
+			Title:    "an old issue",
+			User:     "alice",
+			Created:  start.AddDate(0, 0, -7),
+			Indexed:  day(2),
+			Comments: []*githubtest.Comment{{User: "alice", Created: start.AddDate(0, 0, -6)}},
+		},
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "work-stats",
+			Number:      2,
+			Title:       "an old PR",
+			User:        "alice",
+			Created:     start.AddDate(0, 0, -7),
+			ClosedAt:    start.AddDate(0, 0, -5),
+			Indexed:     day(2),
+			PullRequest: true,
+			Merged:      true,
+		},
+	)
+	authored, reviewed, issues, err := github.IssuesAndPRs(context.Background(), server.Client(), nil, "alice", generic.Range{Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(authored) != 0 || len(reviewed) != 0 || len(issues) != 0 {
+		t.Errorf("got %d authored PRs, %d reviewed PRs, and %d issues, want none", len(authored), len(reviewed), len(issues))
+	}
+	for _, endpoint := range []string{"issue", "comments", "events", "timeline", "reactions", "pull", "merged", "reviews", "review-comments"} {
+		if got := server.Requests(endpoint); got != 0 {
+			t.Errorf("got %d requests for %s, want none", got, endpoint)
+		}
+	}
+}
+
+func TestIssuesAndPRsRateLimit(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	server.AddIssue(&githubtest.Issue{
+		Owner:   "stamblerre",
+		Repo:    "work-stats",
+		Number:  1,
+		Title:   "issue",
+		User:    "alice",
+		Created: day(1),
+	})
+	server.SetRateLimit(1)
+
+	_, _, _, err := github.IssuesAndPRs(context.Background(), server.Client(), nil, "alice", generic.Range{Start: start, End: end})
+	var rateLimitErr *gh.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("got error %v, want a rate limit error", err)
+	}
+}
+
+func TestWasTransferred(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	server.AddIssue(
+		&githubtest.Issue{
+			Owner:         "golang",
+			Repo:          "go",
+			Number:        1,
+			User:          "alice",
+			TransferredTo: "golang/vscode-go",
+		},
+		&githubtest.Issue{
+			Owner:  "golang",
+			Repo:   "vscode-go",
+			Number: 1,
+			User:   "alice",
+		},
+		&githubtest.Issue{
+			Owner:  "golang",
+			Repo:   "go",
+			Number: 2,
+			User:   "alice",
+		},
+	)
+	for _, tt := range []struct {
+		number int32
+		want   bool
+	}{
+		{number: 1, want: true},
+		{number: 2, want: false},
+	} {
+		got, err := github.WasTransferred(context.Background(), server.Client(), "golang", "go", tt.number)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("WasTransferred(golang/go#%d) = %v, want %v", tt.number, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+// ReviewRequests returns the review requests the user received on GitHub PRs
+// during r, along with when they first responded to each.
+//
+// GitHub's search only reports pending review requests, so PRs the user has
+// already reviewed are searched for separately.
+func ReviewRequests(ctx context.Context, client *github.Client, username string, r generic.Range) ([]*generic.ReviewRequest, error) {
+	prs := make(map[string]github.Issue)
+	for _, qualifier := range []string{"review-requested", "reviewed-by"} {
+		query := fmt.Sprintf("is:pr %s:%s updated:%s..%s", qualifier, username, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339))
+		opts := &github.SearchOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+			Sort:        "updated",
+			Order:       "asc",
+		}
+		for {
+			result, resp, err := client.Search.Issues(ctx, query, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, pr := range result.Issues {
+				prs[pr.GetHTMLURL()] = pr
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	var requests []*generic.ReviewRequest
+	for _, pr := range prs {
+		trimmed := strings.TrimPrefix(pr.GetRepositoryURL(), "https://api.github.com/repos/")
+		split := strings.SplitN(trimmed, "/", 2)
+		org, repo := split[0], split[1]
+		// golang PRs are mirrored to Gerrit and reviewed there.
+		if org == "golang" {
+			continue
+		}
+		requestedAt, err := reviewRequestTime(ctx, client, org, repo, pr.GetNumber(), username)
+		if err != nil {
+			return nil, err
+		}
+		if !r.Contains(requestedAt) {
+			continue
+		}
+		respondedAt, err := firstReviewBy(ctx, client, org, repo, pr.GetNumber(), username, requestedAt)
+		if err != nil {
+			return nil, err
+		}
+		status := generic.New
+		if !pr.GetClosedAt().IsZero() {
+			merged, _, err := client.PullRequests.IsMerged(ctx, org, repo, pr.GetNumber())
+			if err != nil {
+				return nil, err
+			}
+			status = generic.Abandoned
+			if merged {
+				status = generic.Merged
+			}
+		}
+		requests = append(requests, &generic.ReviewRequest{
+			Link:        pr.GetHTMLURL(),
+			Repo:        fmt.Sprintf("%s/%s", org, repo),
+			Subject:     pr.GetTitle(),
+			Author:      pr.GetUser().GetLogin(),
+			Reviewer:    username,
+			Status:      status,
+			RequestedAt: requestedAt,
+			RespondedAt: respondedAt,
+		})
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].RequestedAt.Equal(requests[j].RequestedAt) {
+			return requests[i].RequestedAt.Before(requests[j].RequestedAt)
+		}
+		return requests[i].Link < requests[j].Link
+	})
+	return requests, nil
+}
+
+// reviewRequestedEvent is a "review_requested" issue event. go-github's
+// IssueEvent does not include the requested reviewer.
+type reviewRequestedEvent struct {
+	Event             string       `json:"event"`
+	CreatedAt         time.Time    `json:"created_at"`
+	RequestedReviewer *github.User `json:"requested_reviewer"`
+}
+
+// reviewRequestTime returns the time the user was first asked to review a
+// PR, or the zero time if they never were.
+func reviewRequestTime(ctx context.Context, client *github.Client, org, repo string, number int, username string) (time.Time, error) {
+	page := 1
+	for {
+		req, err := client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/issues/%d/events?per_page=100&page=%d", org, repo, number, page), nil)
+		if err != nil {
+			return time.Time{}, err
+		}
+		var events []*reviewRequestedEvent
+		resp, err := client.Do(ctx, req, &events)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, e := range events {
+			if e.Event == "review_requested" && e.RequestedReviewer.GetLogin() == username {
+				return e.CreatedAt, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return time.Time{}, nil
+		}
+		page = resp.NextPage
+	}
+}
+
+// firstReviewBy returns the time of the user's first review of a PR after
+// the given time, or the zero time if there is none.
+func firstReviewBy(ctx context.Context, client *github.Client, org, repo string, number int, username string, after time.Time) (time.Time, error) {
+	var first time.Time
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(ctx, org, repo, number, opts)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, r := range reviews {
+			if r.GetUser().GetLogin() != username {
+				continue
+			}
+			if t := r.GetSubmittedAt(); !t.Before(after) && (first.IsZero() || t.Before(first)) {
+				first = t
+			}
+		}
+		if resp.NextPage == 0 {
+			return first, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,132 @@
+package github_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+	"github.com/stamblerre/work-stats/github"
+	"github.com/stamblerre/work-stats/internal/githubtest"
+)
+
+func TestReviewRequests(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	server.AddIssue(
+		// A PR that alice was asked to review and approved.
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "work-stats",
+			Number:      1,
+			Title:       "generic: add metrics",
+			User:        "bob",
+			Created:     day(2),
+			ClosedAt:    day(4),
+			PullRequest: true,
+			Merged:      true,
+			ReviewRequests: []*githubtest.ReviewRequest{
+				{Reviewer: "alice", Requester: "bob", Created: day(2)},
+			},
+			Reviews: []*githubtest.Review{
+				{User: "alice", Submitted: day(3), State: "APPROVED"},
+			},
+		},
+		// A PR still waiting on alice's review.
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "sheets",
+			Number:      2,
+			Title:       "add colors",
+			User:        "bob",
+			Created:     day(5),
+			PullRequest: true,
+			ReviewRequests: []*githubtest.ReviewRequest{
+				{Reviewer: "bob", Requester: "bob", Created: day(5)},
+				{Reviewer: "alice", Requester: "bob", Created: day(5).Add(time.Hour)},
+			},
+		},
+		// A PR that was closed before alice reviewed it.
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "sheets",
+			Number:      3,
+			Title:       "abandoned idea",
+			User:        "bob",
+			Created:     day(6),
+			ClosedAt:    day(7),
+			PullRequest: true,
+			ReviewRequests: []*githubtest.ReviewRequest{
+				{Reviewer: "alice", Requester: "bob", Created: day(6)},
+			},
+		},
+		// A PR that alice was asked to review before the range.
+		&githubtest.Issue{
+			Owner:       "stamblerre",
+			Repo:        "sheets",
+			Number:      4,
+			Title:       "old change",
+			User:        "bob",
+			Created:     day(1).AddDate(0, -1, 0),
+			PullRequest: true,
+			ReviewRequests: []*githubtest.ReviewRequest{
+				{Reviewer: "alice", Requester: "bob", Created: day(1).AddDate(0, -1, 0)},
+			},
+			Reviews: []*githubtest.Review{
+				{User: "alice", Submitted: day(8), State: "COMMENTED"},
+			},
+		},
+		// golang PRs are reviewed on Gerrit.
+		&githubtest.Issue{
+			Owner:       "golang",
+			Repo:        "tools",
+			Number:      5,
+			Title:       "gopls: fix typo",
+			User:        "bob",
+			Created:     day(9),
+			PullRequest: true,
+			ReviewRequests: []*githubtest.ReviewRequest{
+				{Reviewer: "alice", Requester: "bob", Created: day(9)},
+			},
+		},
+	)
+
+	got, err := github.ReviewRequests(context.Background(), server.Client(), "alice", generic.Range{Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*generic.ReviewRequest{
+		{
+			Link:        "https://github.com/stamblerre/work-stats/pull/1",
+			Repo:        "stamblerre/work-stats",
+			Subject:     "generic: add metrics",
+			Author:      "bob",
+			Reviewer:    "alice",
+			Status:      generic.Merged,
+			RequestedAt: day(2),
+			RespondedAt: day(3),
+		},
+		{
+			Link:        "https://github.com/stamblerre/sheets/pull/2",
+			Repo:        "stamblerre/sheets",
+			Subject:     "add colors",
+			Author:      "bob",
+			Reviewer:    "alice",
+			Status:      generic.New,
+			RequestedAt: day(5).Add(time.Hour),
+		},
+		{
+			Link:        "https://github.com/stamblerre/sheets/pull/3",
+			Repo:        "stamblerre/sheets",
+			Subject:     "abandoned idea",
+			Author:      "bob",
+			Reviewer:    "alice",
+			Status:      generic.Abandoned,
+			RequestedAt: day(6),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected review requests: %s", diff)
+	}
+}
@@ -10,6 +10,7 @@ require (
 	golang.org/x/build v0.0.0-20220318225125-3ab5e7e87a80
 	golang.org/x/oauth2 v0.0.0-20220309155454-6242fa91716a
 	google.golang.org/api v0.73.0
+	google.golang.org/protobuf v1.27.1
 )
 
 require (
@@ -35,5 +36,4 @@ require (
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/genproto v0.0.0-20220322021311-435b647f9ef2 // indirect
 	google.golang.org/grpc v1.45.0 // indirect
-	google.golang.org/protobuf v1.27.1 // indirect
 )
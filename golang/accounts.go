@@ -0,0 +1,349 @@
+package golang
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/build/maintner"
+)
+
+// Accounts are the Gerrit accounts that belong to a user.
+//
+// NoteDB identifies the people who upload, review, and comment on CLs only by
+// their Gerrit account ID, so the user's email addresses alone are not enough
+// to find their activity.
+type Accounts struct {
+	// Emails are the user's email addresses.
+	Emails map[string]bool
+
+	// IDs maps each of the user's account IDs to the email address it was
+	// linked to.
+	IDs map[int]string
+
+	// Evidence counts, for each account ID, the CLs that link it to the
+	// user, by the kind of evidence, such as "owner" or "vote".
+	Evidence map[int]map[string]int
+
+	// Diagnostics describe evidence that was ambiguous or that conflicted
+	// with other evidence, and so was either not used or used only for lack
+	// of better evidence.
+	Diagnostics []*Diagnostic
+}
+
+// DiagnosticKind is the kind of problem a Diagnostic describes.
+type DiagnosticKind int
+
+const (
+	// Ambiguous evidence links the user to more than one account ID, with
+	// no way to tell which one is theirs, or to an account ID that may be
+	// someone else's.
+	Ambiguous = DiagnosticKind(iota)
+	// Conflict evidence links the user to an account ID that other
+	// evidence links to someone else.
+	Conflict
+)
+
+func (k DiagnosticKind) String() string {
+	switch k {
+	case Ambiguous:
+		return "ambiguous"
+	case Conflict:
+		return "conflict"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic describes doubtful evidence about the user's account IDs.
+type Diagnostic struct {
+	Kind DiagnosticKind
+	// IDs are the account IDs involved.
+	IDs []int
+	// Links are the CLs on which the evidence was found.
+	Links []string
+	// Reason explains the problem.
+	Reason string
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s Gerrit account IDs %v: %s (see %s)", d.Kind, d.IDs, d.Reason, strings.Join(d.Links, ", "))
+}
+
+// ResolveAccounts finds the Gerrit account IDs of the user with the given
+// emails. If ids is non-empty, they are the user's account IDs, and the
+// corpus is not searched.
+//
+// Otherwise, the IDs are resolved from the evidence in the corpus:
+//
+//   - the owners of CLs whose commits the user wrote, other than cherry-picks
+//     and CLs imported by bots;
+//   - for users who have never owned a CL, the reviewers who voted on merged
+//     CLs with a Reviewed-by footer for the user.
+//
+// The emails of meta commit authors are not evidence, since they are NoteDB
+// identities, such as "1234@<server UUID>", rather than the user's.
+//
+// An account ID that owns more CLs written by other people than by the user,
+// such as that of someone who uploaded the user's commit, is not used, unless
+// no other account ID is found: then it is used, but reported as Ambiguous,
+// since it may be that of a user who uploads others' commits. It is not an
+// error if no account IDs are found.
+func ResolveAccounts(gerrit *maintner.Gerrit, emails []string, ids []int) (*Accounts, error) {
+	a := &Accounts{
+		Emails:   make(map[string]bool),
+		IDs:      make(map[int]string),
+		Evidence: make(map[int]map[string]int),
+	}
+	for _, e := range emails {
+		a.Emails[e] = true
+	}
+	if len(ids) > 0 {
+		for _, id := range ids {
+			a.IDs[id] = emails[0]
+			a.addEvidence(id, "override", 1)
+		}
+		return a, nil
+	}
+
+	var (
+		// owners counts, for every account ID, the CLs it owns by the email
+		// of their author.
+		owners = make(map[int]map[string]int)
+		// ownerLinks are the user's CLs owned by each account ID.
+		ownerLinks = make(map[int][]string)
+		// votes are the account IDs that voted on each of the merged CLs
+		// that list the user as a reviewer.
+		votes = make(map[string][]int)
+		// reviewerEmails are the user's emails in each CL's Reviewed-by
+		// footers.
+		reviewerEmails = make(map[string]string)
+	)
+	if err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
+		return project.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
+			owner := cl.OwnerID()
+			if cl.Owner() != nil && owner != -1 && owner != gerritbotID && owner != gobotID && !isCherryPick(cl) {
+				email := cl.Owner().Email()
+				if owners[owner] == nil {
+					owners[owner] = make(map[string]int)
+				}
+				owners[owner][email]++
+				if a.Emails[email] {
+					ownerLinks[owner] = append(ownerLinks[owner], link(cl))
+				}
+			}
+			if cl.Status != "merged" {
+				return nil
+			}
+			for _, email := range reviewedBy(cl) {
+				if a.Emails[email] {
+					votes[link(cl)] = voters(cl)
+					reviewerEmails[link(cl)] = email
+					break
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	// Owning CLs is the strongest evidence, unless the account mostly owns
+	// other people's CLs.
+	var (
+		conflicts      []*Diagnostic
+		conflictEmails = make(map[int]string)
+	)
+	for id, links := range ownerLinks {
+		var mine, theirs int
+		var email string
+		for e, n := range owners[id] {
+			if !a.Emails[e] {
+				theirs += n
+				continue
+			}
+			mine += n
+			// Link the ID to the email it owns the most CLs as.
+			if email == "" || n > owners[id][email] || n == owners[id][email] && e < email {
+				email = e
+			}
+		}
+		if theirs > mine {
+			sort.Strings(links)
+			conflicts = append(conflicts, &Diagnostic{
+				Kind:   Conflict,
+				IDs:    []int{id},
+				Links:  links,
+				Reason: fmt.Sprintf("owns %d CLs written by the user, but %d written by others", mine, theirs),
+			})
+			conflictEmails[id] = email
+			continue
+		}
+		a.IDs[id] = email
+		a.addEvidence(id, "owner", len(links))
+	}
+
+	// Votes identify users who have only reviewed CLs. Ignore the CL's owner
+	// and accounts that own other people's CLs, since they cannot be the
+	// user's.
+	var (
+		candidates []map[int]bool
+		voteLinks  []string
+		resolved   = make(map[int][]string)
+	)
+	for l, ids := range votes {
+		set := make(map[int]bool)
+		for _, id := range ids {
+			if _, ok := a.IDs[id]; ok {
+				set = map[int]bool{id: true}
+				break
+			}
+			if owners[id] != nil {
+				continue
+			}
+			set[id] = true
+		}
+		switch len(set) {
+		case 0:
+		case 1:
+			for id := range set {
+				resolved[id] = append(resolved[id], l)
+			}
+		default:
+			candidates = append(candidates, set)
+			voteLinks = append(voteLinks, l)
+		}
+	}
+	// Only fall back to ambiguous votes if no CL's votes resolve the ID.
+	if len(resolved) == 0 && len(candidates) > 0 {
+		set := candidates[0]
+		for _, c := range candidates[1:] {
+			for id := range set {
+				if !c[id] {
+					delete(set, id)
+				}
+			}
+		}
+		if len(set) == 1 {
+			for id := range set {
+				resolved[id] = voteLinks
+			}
+		} else {
+			sort.Strings(voteLinks)
+			a.Diagnostics = append(a.Diagnostics, &Diagnostic{
+				Kind:   Ambiguous,
+				IDs:    sortedIDs(set),
+				Links:  voteLinks,
+				Reason: "several reviewers voted on CLs that list the user as a reviewer",
+			})
+		}
+	}
+	owned := len(a.IDs) > 0
+	for id, links := range resolved {
+		if _, ok := a.IDs[id]; ok {
+			a.addEvidence(id, "vote", len(links))
+			continue
+		}
+		// Owning CLs is stronger evidence than votes.
+		if owned {
+			sort.Strings(links)
+			a.Diagnostics = append(a.Diagnostics, &Diagnostic{
+				Kind:   Conflict,
+				IDs:    []int{id},
+				Links:  links,
+				Reason: "voted on CLs that list the user as a reviewer, but does not own the user's CLs",
+			})
+			continue
+		}
+		a.IDs[id] = reviewerEmails[links[0]]
+		a.addEvidence(id, "vote", len(links))
+	}
+	// Without other evidence, an account that mostly owns other people's
+	// CLs is still used, so that the user's own CLs are not lost.
+	found := len(a.IDs) > 0
+	for _, d := range conflicts {
+		if !found {
+			id := d.IDs[0]
+			a.IDs[id] = conflictEmails[id]
+			a.addEvidence(id, "owner", len(d.Links))
+			d.Kind = Ambiguous
+			d.Reason += ", and no other account is the user's"
+		}
+		a.Diagnostics = append(a.Diagnostics, d)
+	}
+	sort.Slice(a.Diagnostics, func(i, j int) bool {
+		return a.Diagnostics[i].String() < a.Diagnostics[j].String()
+	})
+	return a, nil
+}
+
+// ParseAccountIDs parses a comma-separated list of Gerrit account IDs, such
+// as the value of a -gerrit-account-id flag.
+func ParseAccountIDs(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ids []int
+	for _, field := range strings.Split(s, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Gerrit account ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (a *Accounts) addEvidence(id int, kind string, n int) {
+	if a.Evidence[id] == nil {
+		a.Evidence[id] = make(map[string]int)
+	}
+	a.Evidence[id][kind] += n
+}
+
+// reviewedBy returns the emails in the Reviewed-by footers that Gerrit adds
+// to a CL's commit message when it is merged.
+func reviewedBy(cl *maintner.GerritCL) []string {
+	var emails []string
+	for _, line := range strings.Split(cl.Commit.Msg, "\n") {
+		if !strings.HasPrefix(line, "Reviewed-by: ") {
+			continue
+		}
+		i, j := strings.Index(line, "<"), strings.LastIndex(line, ">")
+		if i < 0 || j < i {
+			continue
+		}
+		emails = append(emails, line[i+1:j])
+	}
+	return emails
+}
+
+// voters returns the account IDs that voted Code-Review on cl, other than
+// its owner and bots.
+func voters(cl *maintner.GerritCL) []int {
+	seen := make(map[int]bool)
+	var ids []int
+	for _, meta := range cl.Metas {
+		if !strings.Contains("\n"+meta.Footer(), "\nLabel: Code-Review=") {
+			continue
+		}
+		id := personToID(meta.Commit.Author)
+		switch {
+		case id == -1, id == cl.OwnerID(), id == gobotID, id == gerritbotID, seen[id]:
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func sortedIDs(set map[int]bool) []int {
+	var ids []int
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,102 @@
+package golang_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stamblerre/work-stats/golang"
+	"github.com/stamblerre/work-stats/internal/maintnertest"
+)
+
+func TestResolveAccounts(t *testing.T) {
+	for _, tt := range []struct {
+		email       string
+		override    []int
+		ids         map[int]string
+		diagnostics []*golang.Diagnostic
+	}{
+		{
+			// Bob uploaded one of Alice's commits, but owns more of his own
+			// CLs, so his account is not Alice's.
+			email: "alice@golang.org",
+			ids:   map[int]string{1001: "alice@golang.org"},
+			diagnostics: []*golang.Diagnostic{{
+				Kind:   golang.Conflict,
+				IDs:    []int{1002},
+				Links:  []string{"go-review.googlesource.com/c/tools/+/1009"},
+				Reason: "owns 1 CLs written by the user, but 2 written by others",
+			}},
+		},
+		{
+			email: "bob@golang.org",
+			ids:   map[int]string{1002: "bob@golang.org"},
+		},
+		{
+			// Carol has only reviewed CLs, and is the only unknown voter on
+			// CL 1003.
+			email: "carol@golang.org",
+			ids:   map[int]string{1003: "carol@golang.org"},
+		},
+		{
+			// Dave and Erin both voted on CL 1008.
+			email: "dave@golang.org",
+			diagnostics: []*golang.Diagnostic{{
+				Kind:   golang.Ambiguous,
+				IDs:    []int{1004, 1005},
+				Links:  []string{"go-review.googlesource.com/c/tools/+/1008"},
+				Reason: "several reviewers voted on CLs that list the user as a reviewer",
+			}},
+		},
+		{
+			email:    "dave@golang.org",
+			override: []int{1004},
+			ids:      map[int]string{1004: "dave@golang.org"},
+		},
+		{
+			// Frank owns more CLs written by others than by himself, but
+			// no other account is his.
+			email: "frank@golang.org",
+			ids:   map[int]string{1006: "frank@golang.org"},
+			diagnostics: []*golang.Diagnostic{{
+				Kind:   golang.Ambiguous,
+				IDs:    []int{1006},
+				Links:  []string{"go-review.googlesource.com/c/tools/+/1011"},
+				Reason: "owns 1 CLs written by the user, but 2 written by others, and no other account is the user's",
+			}},
+		},
+		{
+			email: "nobody@golang.org",
+		},
+		{
+			// Meta commits are authored by NoteDB identities, such as
+			// Carol's, which are not the user's emails.
+			email: fmt.Sprintf("%d@%s", 1003, maintnertest.ServerUUID),
+		},
+	} {
+		a, err := golang.ResolveAccounts(corpus.Gerrit(), []string{tt.email}, tt.override)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.ids, a.IDs, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("%s: unexpected IDs: %s", tt.email, diff)
+		}
+		if diff := cmp.Diff(tt.diagnostics, a.Diagnostics); diff != "" {
+			t.Errorf("%s: unexpected diagnostics: %s", tt.email, diff)
+		}
+	}
+}
+
+func TestParseAccountIDs(t *testing.T) {
+	ids, err := golang.ParseAccountIDs("1001, 1002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{1001, 1002}, ids); diff != "" {
+		t.Errorf("unexpected IDs: %s", diff)
+	}
+	if _, err := golang.ParseAccountIDs("alice"); err == nil {
+		t.Errorf("ParseAccountIDs(%q) succeeded, want error", "alice")
+	}
+}
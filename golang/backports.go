@@ -0,0 +1,40 @@
+package golang
+
+import (
+	"sort"
+
+	"github.com/stamblerre/work-stats/generic"
+	"golang.org/x/build/maintner"
+)
+
+// Backports returns the CLs on release branches that the user sent during r,
+// such as cherry-picks of master CLs for a minor release. A cherry-pick
+// keeps the author of the original commit, so the user sent a backport if
+// they own it in Gerrit, even if they did not write the original CL.
+func Backports(gerrit *maintner.Gerrit, accounts *Accounts, r generic.Range) ([]*generic.Changelist, error) {
+	var backports []*generic.Changelist
+	if err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
+		return project.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
+			if !generic.IsReleaseBranch(cl.Branch()) {
+				return nil
+			}
+			if cl.Status == "abandoned" {
+				return nil
+			}
+			if !r.Contains(cl.Commit.CommitTime) {
+				return nil
+			}
+			if _, ok := accounts.IDs[cl.OwnerID()]; !ok {
+				return nil
+			}
+			backports = append(backports, GerritToGenericCL(cl))
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(backports, func(i, j int) bool {
+		return backports[i].Link < backports[j].Link
+	})
+	return backports, nil
+}
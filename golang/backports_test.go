@@ -0,0 +1,38 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+	"github.com/stamblerre/work-stats/golang"
+)
+
+func TestBackports(t *testing.T) {
+	for _, tt := range []struct {
+		email string
+		want  []string
+	}{
+		// Bob cherry-picked Alice's CL, so the backport is his.
+		{
+			email: "bob@golang.org",
+			want:  []string{"go-review.googlesource.com/c/tools/+/1007"},
+		},
+		{
+			email: "alice@golang.org",
+		},
+	} {
+		backports, err := golang.Backports(corpus.Gerrit(), accounts(t, tt.email), generic.Range{Start: start, End: end})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, links(backports)); diff != "" {
+			t.Errorf("%s: unexpected backports: %s", tt.email, diff)
+		}
+		for _, cl := range backports {
+			if want := "go-review.googlesource.com/c/tools/+/1001"; cl.Origin != want {
+				t.Errorf("%s: got origin %q, want %q", cl.Link, cl.Origin, want)
+			}
+		}
+	}
+}
@@ -2,9 +2,7 @@
 package golang
 
 import (
-	"errors"
 	"fmt"
-	"log"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,40 +17,19 @@ const (
 	gerritbotID = 12446
 )
 
-func Changelists(gerrit *maintner.Gerrit, emails []string, start, end time.Time) (authored, reviewed []*generic.Changelist, err error) {
-	emailset := make(map[string]bool)
-	for _, e := range emails {
-		emailset[e] = true
-	}
+// Changelists returns the CLs that the user with the given accounts authored
+// and reviewed during r.
+func Changelists(gerrit *maintner.Gerrit, accounts *Accounts, r generic.Range) (authored, reviewed []*generic.Changelist, err error) {
 	authoredMap := make(map[string]*generic.Changelist)
 	reviewedMap := make(map[string]*generic.Changelist)
-	ownerIDs, err := OwnerIDs(gerrit, emailset)
-	if err != nil {
-		return nil, nil, err
-	}
 	// Collect all CLs authored by the user.
 	if err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
 		err := project.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
-			if cl.Owner() == nil || !emailset[cl.Owner().Email()] {
-				return nil
-			}
+			// Abandoned CLs are reported by Abandoned.
 			if cl.Status == "abandoned" {
 				return nil
 			}
-			key := key(cl)
-
-			var match bool
-			for _, meta := range cl.Metas {
-				if !inScope(cl.Commit.CommitTime, start, end) {
-					continue
-				}
-				id := personToID(meta.Commit.Author)
-				if ownerID := ownerIDs[key]; ownerID == id {
-					match = true
-					break
-				}
-			}
-			if !match {
+			if !authoredBy(accounts, cl, r) {
 				return nil
 			}
 			genericCL := GerritToGenericCL(cl)
@@ -63,25 +40,31 @@ func Changelists(gerrit *maintner.Gerrit, emails []string, start, end time.Time)
 	}); err != nil {
 		return nil, nil, err
 	}
+
 	// Collect all the CLs reviewed by the user.
 	if err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
 		// We have to do this call separately, since we have to make sure that the owner ID has been set correctly.
 		return project.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
 			// If it was the user owns the CL, they cannot be its reviewer.
-			if cl.Owner() != nil && emailset[cl.Owner().Email()] {
+			// A cherry-pick keeps the original commit's author, so the
+			// user may still review someone else's backport of their CL.
+			if cl.Owner() != nil && accounts.Emails[cl.Owner().Email()] && !isCherryPick(cl) {
 				return nil
 			}
-			// This could be a CL imported as a PR. Skip it and handle it via GitHub.
-			if cl.OwnerID() == gerritbotID || cl.OwnerID() == gobotID {
+			if _, ok := accounts.IDs[cl.OwnerID()]; ok {
+				return nil
+			}
+			// CLs imported from GitHub PRs are reviewed in Gerrit like any
+			// other CL, but gobot's CLs are automated.
+			if cl.OwnerID() == gobotID {
 				return nil
 			}
 			if cl.Status == "abandoned" {
 				return nil
 			}
-			key := key(cl)
 			var match bool
 			for _, msg := range cl.Messages {
-				if !inScope(msg.Date, start, end) {
+				if !r.Contains(msg.Date) {
 					continue
 				}
 				if msg.Author == nil {
@@ -89,10 +72,10 @@ func Changelists(gerrit *maintner.Gerrit, emails []string, start, end time.Time)
 				}
 				// If the user's email is not actually tracked.
 				// Not sure why this happens for some people, but not others.
-				if id := personToID(msg.Author); ownerIDs[key] == int(id) {
+				if _, ok := accounts.IDs[personToID(msg.Author)]; ok {
 					match = true
 					break
-				} else if emailset[msg.Author.Email()] {
+				} else if accounts.Emails[msg.Author.Email()] {
 					match = true
 					break
 				}
@@ -122,47 +105,48 @@ func Changelists(gerrit *maintner.Gerrit, emails []string, start, end time.Time)
 	return authored, reviewed, nil
 }
 
-type GerritIDKey struct {
-	project, branch, status string
-}
-
-func OwnerIDs(gerrit *maintner.Gerrit, emailset map[string]bool) (map[GerritIDKey]int, error) {
-	ownerIDs := make(map[GerritIDKey]int)
-	err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
+// Abandoned returns the CLs that the user with the given accounts authored
+// and abandoned during r, such as prototypes and other exploratory work.
+func Abandoned(gerrit *maintner.Gerrit, accounts *Accounts, r generic.Range) ([]*generic.Changelist, error) {
+	var abandoned []*generic.Changelist
+	if err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
 		return project.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
-			if cl.Owner() == nil || !emailset[cl.Owner().Email()] {
+			if cl.Status != "abandoned" {
 				return nil
 			}
-			// Skip PRs imported as CLs.
-			// These are complicated and probably should be handled separately.
-			if cl.OwnerID() == gerritbotID {
-				return nil
-			}
-			// This shouldn't happen, but gobot also has a known ID.
-			if cl.OwnerID() == gobotID {
+			if !authoredBy(accounts, cl, r) {
 				return nil
 			}
-			// Skip abandoned CLs.
-			if cl.Status == "abandoned" {
-				return nil
-			}
-			// Skip cherrypicks.
-			if cl.Footer("Reviewed-on:") != "https://"+link(cl) {
-				return nil
-			}
-			k := key(cl)
-			if id, ok := ownerIDs[k]; !ok {
-				ownerIDs[k] = cl.OwnerID()
-			} else if id != cl.OwnerID() {
-				log.Printf("Conflicting owner IDs (have %v, got %v) caused by %v with key %v. Ignoring that CL, please file an issue if you were involved in the CL.", id, cl.OwnerID(), link(cl), k)
-			}
+			abandoned = append(abandoned, GerritToGenericCL(cl))
 			return nil
 		})
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(abandoned, func(i, j int) bool {
+		return abandoned[i].Link < abandoned[j].Link
 	})
-	if len(ownerIDs) == 0 {
-		return nil, errors.New("unable to collect review data, user has never authored a CL, so the reviewer ID cannot be matched")
+	return abandoned, nil
+}
+
+// authoredBy reports whether the user with the given accounts authored cl
+// during r. CLs on release branches are reported by Backports instead.
+func authoredBy(accounts *Accounts, cl *maintner.GerritCL, r generic.Range) bool {
+	if cl.Owner() == nil || !accounts.Emails[cl.Owner().Email()] {
+		return false
+	}
+	if generic.IsReleaseBranch(cl.Branch()) {
+		return false
+	}
+	if !r.Contains(cl.Commit.CommitTime) {
+		return false
 	}
-	return ownerIDs, err
+	// PRs imported by gerritbot keep the commit author of the original PR,
+	// so they belong to the user if their owner email matches, even though
+	// gerritbot owns them in Gerrit. Otherwise, the user must own the CL in
+	// Gerrit, since someone else may have uploaded their commit.
+	_, ok := accounts.IDs[cl.OwnerID()]
+	return ok || cl.OwnerID() == gerritbotID
 }
 
 // personToID returns the Gerrit ID for a given name of the form "Gerrit User 1234".
@@ -184,12 +168,20 @@ func personToID(person *maintner.GitPerson) int {
 	return int(id)
 }
 
-func key(cl *maintner.GerritCL) GerritIDKey {
-	return GerritIDKey{
-		project: cl.Project.Project(),
-		branch:  cl.Branch(),
-		status:  cl.Status,
+// isCherryPick reports whether cl was cherry-picked from another CL.
+func isCherryPick(cl *maintner.GerritCL) bool {
+	return origin(cl) != "" || strings.Contains(cl.Commit.Msg, "(cherry picked from commit ")
+}
+
+// origin returns the link to the CL that cl was cherry-picked from, or "" if
+// it is unknown. A cherry-pick keeps the Reviewed-on footer of the original
+// CL's commit, and gains its own when it is merged.
+func origin(cl *maintner.GerritCL) string {
+	reviewedOn := cl.Footer("Reviewed-on:")
+	if reviewedOn == "" || reviewedOn == "https://"+link(cl) {
+		return ""
 	}
+	return strings.TrimPrefix(reviewedOn, "https://")
 }
 
 func GerritToGenericCL(cl *maintner.GerritCL) *generic.Changelist {
@@ -210,9 +202,19 @@ func GerritToGenericCL(cl *maintner.GerritCL) *generic.Changelist {
 	for _, msg := range cl.Messages {
 		comments = append(comments, msg.Message)
 	}
-	var filenames []string
+	var (
+		filenames      []string
+		added, deleted int
+	)
 	for _, f := range cl.Commit.Files {
 		filenames = append(filenames, f.GetFile())
+		added += int(f.GetAdded())
+		deleted += int(f.GetDeleted())
+	}
+	// Gerrit replaced draft CLs with work-in-progress CLs.
+	status := toStatus(cl.Status)
+	if status == generic.New && cl.WorkInProgress() {
+		status = generic.Draft
 	}
 	return &generic.Changelist{
 		Number:           int(cl.Number),
@@ -223,10 +225,17 @@ func GerritToGenericCL(cl *maintner.GerritCL) *generic.Changelist {
 		Comments:         comments,
 		Repo:             cl.Project.Project(),
 		Branch:           cl.Branch(),
-		Status:           toStatus(cl.Status),
+		Status:           status,
+		CreatedAt:        cl.Created,
+		FirstReviewAt:    toFirstReviewTime(cl),
 		MergedAt:         toMergeTime(cl),
 		AssociatedIssues: issues,
 		AffectedFiles:    filenames,
+		Patchsets:        int(cl.Version),
+		FilesChanged:     len(cl.Commit.Files),
+		LinesAdded:       added,
+		LinesDeleted:     deleted,
+		Origin:           origin(cl),
 	}
 }
 
@@ -234,10 +243,6 @@ func link(cl *maintner.GerritCL) string {
 	return fmt.Sprintf("go-review.googlesource.com/c/%s/+/%v", cl.Project.Project(), cl.Number)
 }
 
-func inScope(t, start, end time.Time) bool {
-	return t.After(start) && t.Before(end)
-}
-
 func toStatus(s string) generic.ChangelistStatus {
 	switch s {
 	case "merged":
@@ -263,3 +268,17 @@ func toMergeTime(cl *maintner.GerritCL) time.Time {
 	}
 	return time.Time{}
 }
+
+// toFirstReviewTime returns the time of the first message on the CL from
+// someone other than its owner, ignoring bots.
+func toFirstReviewTime(cl *maintner.GerritCL) time.Time {
+	owner := cl.OwnerID()
+	for _, msg := range cl.Messages {
+		switch id := personToID(msg.Author); {
+		case id == -1, id == owner, id == gobotID, id == gerritbotID:
+			continue
+		}
+		return msg.Date
+	}
+	return time.Time{}
+}
@@ -3,93 +3,556 @@ package golang_test
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stamblerre/work-stats/generic"
 	"github.com/stamblerre/work-stats/golang"
+	"github.com/stamblerre/work-stats/internal/maintnertest"
 	"golang.org/x/build/maintner"
-	"golang.org/x/build/maintner/godata"
 )
 
-var gerrit *maintner.Gerrit
+var corpus *maintner.Corpus
+
+var (
+	alice     = &maintnertest.GerritAccount{ID: 1001, Name: "Alice Gopher", Email: "alice@golang.org"}
+	bob       = &maintnertest.GerritAccount{ID: 1002, Name: "Bob Gopher", Email: "bob@golang.org"}
+	gerritbot = &maintnertest.GerritAccount{ID: 12446, Name: "Gerrit Bot", Email: "letsusegerrit@gmail.com"}
+
+	// Carol, Dave, and Erin have only reviewed CLs.
+	carol = &maintnertest.GerritAccount{ID: 1003, Name: "Carol Gopher", Email: "carol@golang.org"}
+	dave  = &maintnertest.GerritAccount{ID: 1004, Name: "Dave Gopher", Email: "dave@golang.org"}
+	erin  = &maintnertest.GerritAccount{ID: 1005, Name: "Erin Gopher", Email: "erin@golang.org"}
+
+	// Frank mostly uploads other people's commits.
+	frank = &maintnertest.GerritAccount{ID: 1006, Name: "Frank Gopher", Email: "frank@golang.org"}
+
+	aliceGH = &maintnertest.GitHubUser{ID: 1, Login: "alice"}
+	bobGH   = &maintnertest.GitHubUser{ID: 2, Login: "bob"}
+
+	// The tests collect data for March 2022.
+	start = time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end   = time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// day returns noon on the given day in March 2022.
+func day(d int) time.Time {
+	return time.Date(2022, time.March, d, 12, 0, 0, 0, time.UTC)
+}
 
 func TestMain(m *testing.M) {
-	corpus, err := godata.Get(context.Background())
+	b := maintnertest.NewBuilder()
+	addFixtures(b)
+	var err error
+	corpus, err = b.Corpus(context.Background())
 	if err != nil {
+		log.Print(err)
 		os.Exit(1)
 	}
-	gerrit = corpus.Gerrit()
 	os.Exit(m.Run())
 }
 
-func TestOwnerIds(t *testing.T) {
+func addFixtures(b *maintnertest.Builder) {
+	b.AddIssue(
+		&maintnertest.Issue{
+			Number:    100,
+			Title:     "x/tools/internal/lsp: hover is broken",
+			User:      aliceGH,
+			Created:   day(1),
+			ClosedAt:  day(4),
+			ClosedBy:  aliceGH,
+			Labels:    []string{"gopls"},
+			Milestone: "gopls/v0.8.2",
+			Comments: []*maintnertest.Comment{
+				{User: bobGH, Created: day(2), Body: "I can reproduce this."},
+			},
+			Events: []*maintnertest.Event{
+				{Type: "closed", Actor: aliceGH, Created: day(4)},
+			},
+		},
+		&maintnertest.Issue{
+			Number:  101,
+			Title:   "cmd/go: build fails",
+			User:    bobGH,
+			Created: day(3),
+			Body:    "cc @alice",
+			Comments: []*maintnertest.Comment{
+				{User: aliceGH, Created: day(5), Body: "Which version of Go?"},
+				{User: bobGH, Created: day(5), Body: "Tip."},
+				{User: aliceGH, Created: day(6), Body: "Thanks, @bob."},
+			},
+			Events: []*maintnertest.Event{
+				{Type: "labeled", Actor: aliceGH, Created: day(5), Label: "NeedsInvestigation"},
+				{Type: "milestoned", Actor: aliceGH, Created: day(5), Milestone: "Go1.19"},
+				{Type: "assigned", Actor: aliceGH, Created: day(6), Assignee: bobGH},
+				{Type: "labeled", Actor: bobGH, Created: day(6), Label: "GoCommand"},
+			},
+		},
+		&maintnertest.Issue{
+			Number:      102,
+			Title:       "cmd/go: fix the build",
+			User:        aliceGH,
+			Created:     day(7),
+			PullRequest: true,
+		},
+		&maintnertest.Issue{
+			Repo:    "vscode-go",
+			Owner:   "golang",
+			Number:  5,
+			Title:   "debug: breakpoints are ignored",
+			User:    aliceGH,
+			Created: day(10),
+		},
+		&maintnertest.Issue{
+			Number:  103,
+			Title:   "cmd/go: old bug",
+			User:    aliceGH,
+			Created: day(1).AddDate(0, -2, 0),
+			Comments: []*maintnertest.Comment{
+				{User: aliceGH, Created: day(1).AddDate(0, -2, 0), Body: "Still broken."},
+			},
+		},
+	)
+	b.AddCL(
+		// Authored by Alice, reviewed by Bob, merged in March.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1001,
+			Patchsets: []*maintnertest.Patchset{
+				{
+					Author: alice,
+					Time:   day(2),
+					Msg:    "internal/lsp: fix hover\n\nFixes golang/go#100\n\nChange-Id: I0000000000000000000000000000000000001001\n",
+					Files:  []*maintnertest.File{{Name: "internal/lsp/hover.go", Added: 10, Deleted: 2}},
+				},
+				{
+					Author: alice,
+					Time:   day(4),
+					Msg:    "internal/lsp: fix hover\n\nFixes golang/go#100\n\nChange-Id: I0000000000000000000000000000000000001001\nReviewed-on: https://go-review.googlesource.com/c/tools/+/1001\n",
+					Files:  []*maintnertest.File{{Name: "internal/lsp/hover.go", Added: 10, Deleted: 2}},
+				},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(alice, day(2), 1),
+				maintnertest.AddReviewers(alice, day(2).Add(time.Hour), 1, bob),
+				maintnertest.Reply(bob, day(3), 1, "LGTM", "Code-Review+2"),
+				maintnertest.Merge(bob, day(4), 2),
+			},
+		},
+		// Authored by Alice, abandoned.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1002,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: alice, Time: day(5), Msg: "internal/lsp: try something\n\nChange-Id: I0000000000000000000000000000000000001002\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(alice, day(5), 1),
+				maintnertest.Abandon(alice, day(6), 1),
+			},
+		},
+		// Authored by Bob, reviewed by Alice and Carol, merged in March.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1003,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: bob, Time: day(7), Msg: "gopls: update docs\n\nChange-Id: I0000000000000000000000000000000000001003\n"},
+				{Author: bob, Time: day(9), Msg: "gopls: update docs\n\nChange-Id: I0000000000000000000000000000000000001003\nReviewed-on: https://go-review.googlesource.com/c/tools/+/1003\nReviewed-by: Alice Gopher <alice@golang.org>\nReviewed-by: Carol Gopher <carol@golang.org>\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(bob, day(7), 1),
+				maintnertest.AddReviewers(bob, day(7).Add(time.Hour), 1, alice),
+				maintnertest.Reply(alice, day(8), 1, "", "Code-Review+2"),
+				maintnertest.Reply(carol, day(8).Add(time.Hour), 1, "", "Code-Review+1"),
+				maintnertest.Merge(alice, day(9), 2),
+			},
+		},
+		// Authored by Alice, merged before March.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1004,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: alice, Time: day(1).AddDate(0, -1, 0), Msg: "internal/lsp: old change\n\nChange-Id: I0000000000000000000000000000000000001004\nReviewed-on: https://go-review.googlesource.com/c/tools/+/1004\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(alice, day(1).AddDate(0, -1, 0), 1),
+				maintnertest.Merge(bob, day(1).AddDate(0, -1, 0), 1),
+			},
+		},
+		// Imported from a GitHub PR, commented on by Alice.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1005,
+			Patchsets: []*maintnertest.Patchset{
+				{
+					Author: &maintnertest.GerritAccount{Name: "Contributor", Email: "contributor@example.com"},
+					Time:   day(10),
+					Msg:    "gopls: fix typo\n\nChange-Id: I0000000000000000000000000000000000001005\nGitHub-Pull-Request: golang/tools#7\n",
+				},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(gerritbot, day(10), 1),
+				// Alice added herself, so this isn't a review request.
+				maintnertest.AddReviewers(alice, day(11), 1, alice),
+				maintnertest.Reply(alice, day(11), 1, "Thanks!"),
+			},
+		},
+		// Authored by Bob, waiting on Alice's review.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1006,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: bob, Time: day(12), Msg: "gopls: add a setting\n\nChange-Id: I0000000000000000000000000000000000001006\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(bob, day(12), 1),
+				maintnertest.AddReviewers(bob, day(12), 1, alice),
+				// Bob's own reply doesn't count as a response.
+				maintnertest.Reply(bob, day(13), 1, "PTAL"),
+			},
+		},
+		// Alice's CL 1001, cherry-picked to a release branch by Bob and
+		// reviewed by Alice.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1007,
+			Branch:  "gopls-release-branch.0.8",
+			Patchsets: []*maintnertest.Patchset{
+				{
+					Author: alice,
+					Time:   day(14),
+					Msg:    "[gopls-release-branch.0.8] internal/lsp: fix hover\n\nFixes golang/go#100\n\nChange-Id: I0000000000000000000000000000000000001001\nReviewed-on: https://go-review.googlesource.com/c/tools/+/1001\n(cherry picked from commit 0000000000000000000000000000000000001001)\n",
+				},
+				{
+					Author: alice,
+					Time:   day(15),
+					Msg:    "[gopls-release-branch.0.8] internal/lsp: fix hover\n\nFixes golang/go#100\n\nChange-Id: I0000000000000000000000000000000000001001\nReviewed-on: https://go-review.googlesource.com/c/tools/+/1001\n(cherry picked from commit 0000000000000000000000000000000000001001)\nReviewed-on: https://go-review.googlesource.com/c/tools/+/1007\n",
+				},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(bob, day(14), 1),
+				maintnertest.Reply(alice, day(15), 1, "", "Code-Review+2"),
+				maintnertest.Merge(bob, day(15), 2),
+			},
+		},
+		// Authored by Alice, reviewed by both Dave and Erin in January.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1008,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: alice, Time: day(1).AddDate(0, -2, 0), Msg: "internal/lsp: older change\n\nChange-Id: I0000000000000000000000000000000000001008\n"},
+				{Author: alice, Time: day(3).AddDate(0, -2, 0), Msg: "internal/lsp: older change\n\nChange-Id: I0000000000000000000000000000000000001008\nReviewed-on: https://go-review.googlesource.com/c/tools/+/1008\nReviewed-by: Dave Gopher <dave@golang.org>\nReviewed-by: Erin Gopher <erin@golang.org>\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(alice, day(1).AddDate(0, -2, 0), 1),
+				maintnertest.Reply(dave, day(2).AddDate(0, -2, 0), 1, "", "Code-Review+2"),
+				maintnertest.Reply(erin, day(2).AddDate(0, -2, 0), 1, "", "Code-Review+1"),
+				maintnertest.Merge(alice, day(3).AddDate(0, -2, 0), 2),
+			},
+		},
+		// Authored by Alice, still a work in progress.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1010,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: alice, Time: day(16), Msg: "gopls: prototype a new feature\n\nChange-Id: I0000000000000000000000000000000000001010\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(alice, day(16), 1),
+				maintnertest.WorkInProgress(alice, day(16).Add(time.Hour), 1),
+			},
+		},
+		// Alice's commit, uploaded by Bob in January.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1009,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: alice, Time: day(4).AddDate(0, -2, 0), Msg: "internal/lsp: alice's idea\n\nChange-Id: I0000000000000000000000000000000000001009\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(bob, day(4).AddDate(0, -2, 0), 1),
+			},
+		},
+		// Authored by Frank, merged in March.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1011,
+			Patchsets: []*maintnertest.Patchset{
+				{Author: frank, Time: day(17), Msg: "gopls: fix a crash\n\nChange-Id: I0000000000000000000000000000000000001011\n"},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(frank, day(17), 1),
+				maintnertest.Merge(frank, day(18), 1),
+			},
+		},
+		// Commits by people without Gerrit accounts, uploaded by Frank in
+		// January.
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1012,
+			Patchsets: []*maintnertest.Patchset{
+				{
+					Author: &maintnertest.GerritAccount{Name: "Grace", Email: "grace@example.com"},
+					Time:   day(5).AddDate(0, -2, 0),
+					Msg:    "gopls: grace's change\n\nChange-Id: I0000000000000000000000000000000000001012\n",
+				},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(frank, day(5).AddDate(0, -2, 0), 1),
+			},
+		},
+		&maintnertest.CL{
+			Project: "tools",
+			Number:  1013,
+			Patchsets: []*maintnertest.Patchset{
+				{
+					Author: &maintnertest.GerritAccount{Name: "Heidi", Email: "heidi@example.com"},
+					Time:   day(6).AddDate(0, -2, 0),
+					Msg:    "gopls: heidi's change\n\nChange-Id: I0000000000000000000000000000000000001013\n",
+				},
+			},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(frank, day(6).AddDate(0, -2, 0), 1),
+			},
+		},
+	)
+}
+
+func TestChangelists(t *testing.T) {
 	for _, tt := range []struct {
-		email string
-		ids   map[int]bool
+		emails             []string
+		start, end         time.Time
+		authored, reviewed []string
 	}{
 		{
-			email: "rstambler@golang.org",
-			ids: map[int]bool{
-				16140: true,
+			emails: []string{"alice@golang.org"},
+			start:  start,
+			end:    end,
+			authored: []string{
+				"go-review.googlesource.com/c/tools/+/1001",
+				"go-review.googlesource.com/c/tools/+/1010",
+			},
+			reviewed: []string{
+				"go-review.googlesource.com/c/tools/+/1003",
+				"go-review.googlesource.com/c/tools/+/1005",
+				"go-review.googlesource.com/c/tools/+/1007",
+			},
+		},
+		{
+			// CL 1005 was imported from a GitHub PR by gerritbot, so it is
+			// credited to the PR's author, who owns no CLs in Gerrit.
+			emails:   []string{"contributor@example.com"},
+			start:    start,
+			end:      end,
+			authored: []string{"go-review.googlesource.com/c/tools/+/1005"},
+		},
+		{
+			emails: []string{"bob@golang.org"},
+			start:  start,
+			end:    end,
+			authored: []string{
+				"go-review.googlesource.com/c/tools/+/1003",
+				"go-review.googlesource.com/c/tools/+/1006",
 			},
+			reviewed: []string{"go-review.googlesource.com/c/tools/+/1001"},
+		},
+		{
+			// Carol has never owned a CL, but her account is known from
+			// her votes.
+			emails:   []string{"carol@golang.org"},
+			start:    start,
+			end:      end,
+			reviewed: []string{"go-review.googlesource.com/c/tools/+/1003"},
+		},
+		{
+			// Frank's account mostly owns other people's CLs, but is still
+			// used for his own.
+			emails:   []string{"frank@golang.org"},
+			start:    start,
+			end:      end,
+			authored: []string{"go-review.googlesource.com/c/tools/+/1011"},
+		},
+		{
+			emails:   []string{"alice@golang.org"},
+			start:    start.AddDate(0, -1, 0),
+			end:      start,
+			authored: []string{"go-review.googlesource.com/c/tools/+/1004"},
+		},
+		{
+			emails: []string{"alice@golang.org"},
+			start:  day(20),
+			end:    end,
 		},
 	} {
-		ids, err := golang.OwnerIDs(gerrit, map[string]bool{
-			tt.email: true,
-		})
+		authored, reviewed, err := golang.Changelists(corpus.Gerrit(), accounts(t, tt.emails...), generic.Range{Start: tt.start, End: tt.end})
 		if err != nil {
-			t.Error(err)
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.authored, links(authored)); diff != "" {
+			t.Errorf("%v: unexpected authored CLs: %s", tt.emails, diff)
+		}
+		if diff := cmp.Diff(tt.reviewed, links(reviewed)); diff != "" {
+			t.Errorf("%v: unexpected reviewed CLs: %s", tt.emails, diff)
 		}
-		idset := make(map[int]bool)
-		for _, id := range ids {
-			idset[id] = true
+	}
+}
+
+func TestAbandoned(t *testing.T) {
+	for _, tt := range []struct {
+		email string
+		want  []string
+	}{
+		{
+			email: "alice@golang.org",
+			want:  []string{"go-review.googlesource.com/c/tools/+/1002"},
+		},
+		{
+			email: "bob@golang.org",
+		},
+	} {
+		abandoned, err := golang.Abandoned(corpus.Gerrit(), accounts(t, tt.email), generic.Range{Start: start, End: end})
+		if err != nil {
+			t.Fatal(err)
 		}
-		if len(idset) != len(tt.ids) {
-			t.Errorf("got %v, expected %v", idset, tt.ids)
+		if diff := cmp.Diff(tt.want, links(abandoned)); diff != "" {
+			t.Errorf("%s: unexpected abandoned CLs: %s", tt.email, diff)
 		}
-		for id := range idset {
-			if !tt.ids[id] {
-				t.Errorf("unexpected ID %v", id)
+		for _, cl := range abandoned {
+			if cl.Status != generic.Abandoned {
+				t.Errorf("%s: got status %v, want %v", cl.Link, cl.Status, generic.Abandoned)
 			}
 		}
 	}
 }
 
+// accounts resolves the accounts of the user with the given emails.
+func accounts(t *testing.T, emails ...string) *golang.Accounts {
+	t.Helper()
+	a, err := golang.ResolveAccounts(corpus.Gerrit(), emails, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func links(cls []*generic.Changelist) []string {
+	var links []string
+	for _, cl := range cls {
+		links = append(links, cl.Link)
+	}
+	return links
+}
+
 func TestGerritToGenericCL(t *testing.T) {
 	for _, tt := range []struct {
 		want *generic.Changelist
-	}{{
-		want: &generic.Changelist{
-			Number:  352613,
-			Link:    "go-review.googlesource.com/c/vscode-go/+/352613",
-			Subject: "goSurvey: consolidate gopls survey and go developer survey logic",
-			Message: `goSurvey: consolidate gopls survey and go developer survey logic
+	}{
+		{
+			want: &generic.Changelist{
+				Number:  1001,
+				Link:    "go-review.googlesource.com/c/tools/+/1001",
+				Subject: "internal/lsp: fix hover",
+				Message: `internal/lsp: fix hover
 
-DO NOT REVIEW
+Fixes golang/go#100
 
-Change-Id: I4409b7d41556a9a22f90c7c865ebdcd6acbc5df0
+Change-Id: I0000000000000000000000000000000000001001
+Reviewed-on: https://go-review.googlesource.com/c/tools/+/1001
 `,
-			Branch:        "master",
-			Author:        "rstambler@golang.org",
-			Repo:          "vscode-go",
-			Status:        generic.Abandoned,
-			AffectedFiles: []string{"src/goDeveloperSurvey.ts", "src/goMain.ts", "src/goplsSurvey.ts", "test/gopls/survey.test.ts"},
-		},
-	}} {
-		cl, err := fetchCL(gerrit, tt.want.Repo, int32(tt.want.Number))
+				Comments:      []string{"Patch Set 1: Code-Review+2\n\nLGTM"},
+				Branch:        "master",
+				Author:        "alice@golang.org",
+				Repo:          "tools",
+				Status:        generic.Merged,
+				CreatedAt:     day(2),
+				FirstReviewAt: day(3),
+				MergedAt:      day(4),
+				AssociatedIssues: []*generic.Issue{{
+					Number:     100,
+					Link:       "github.com/golang/go/issues/100",
+					Repo:       "golang/go",
+					Title:      "x/tools/internal/lsp: hover is broken",
+					OpenedBy:   "alice",
+					ClosedBy:   "alice",
+					DateOpened: day(1),
+					DateClosed: day(4),
+					Labels:     []string{"gopls"},
+					Milestone:  "gopls/v0.8.2",
+				}},
+				AffectedFiles: []string{"internal/lsp/hover.go"},
+				Patchsets:     2,
+				FilesChanged:  1,
+				LinesAdded:    10,
+				LinesDeleted:  2,
+			},
+		},
+		{
+			want: &generic.Changelist{
+				Number:        1002,
+				Link:          "go-review.googlesource.com/c/tools/+/1002",
+				Subject:       "internal/lsp: try something",
+				Message:       "internal/lsp: try something\n\nChange-Id: I0000000000000000000000000000000000001002\n",
+				Branch:        "master",
+				Author:        "alice@golang.org",
+				Repo:          "tools",
+				Status:        generic.Abandoned,
+				CreatedAt:     day(5),
+				AffectedFiles: nil,
+				Patchsets:     1,
+			},
+		},
+		{
+			// Work-in-progress CLs are drafts.
+			want: &generic.Changelist{
+				Number:    1010,
+				Link:      "go-review.googlesource.com/c/tools/+/1010",
+				Subject:   "gopls: prototype a new feature",
+				Message:   "gopls: prototype a new feature\n\nChange-Id: I0000000000000000000000000000000000001010\n",
+				Branch:    "master",
+				Author:    "alice@golang.org",
+				Repo:      "tools",
+				Status:    generic.Draft,
+				CreatedAt: day(16),
+				Patchsets: 1,
+			},
+		},
+	} {
+		cl, err := fetchCL(corpus.Gerrit(), tt.want.Repo, int32(tt.want.Number))
 		if err != nil {
 			t.Fatal(err)
 		}
 		got := golang.GerritToGenericCL(cl)
-		if diff := cmp.Diff(got, tt.want, cmpopts.IgnoreFields(generic.Changelist{}, "Comments")); diff != "" {
+		if diff := cmp.Diff(tt.want, got); diff != "" {
 			t.Errorf("got unexpected results: %s", diff)
 		}
 	}
 }
 
+func TestGerritCLComponents(t *testing.T) {
+	m, err := generic.ParseComponentMap([]byte(`
+[golang/tools]
+internal/lsp/  gopls
+
+[golang/go]
+internal/      runtime
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl, err := fetchCL(corpus.Gerrit(), "tools", 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Gerrit CLs record only the project, such as "tools".
+	got := m.Components(golang.GerritToGenericCL(cl))
+	if diff := cmp.Diff([]string{"gopls"}, got); diff != "" {
+		t.Errorf("unexpected components: %s", diff)
+	}
+}
+
 func fetchCL(gerrit *maintner.Gerrit, repo string, number int32) (*maintner.GerritCL, error) {
 	var result *maintner.GerritCL
 	if err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
@@ -2,17 +2,38 @@ package golang
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/stamblerre/work-stats/generic"
 	"golang.org/x/build/maintner"
 )
 
-func Issues(github *maintner.GitHub, repository, username string, start, end time.Time) ([]*generic.Issue, error) {
+// gopherbot closes issues when the CLs that fix them are merged.
+const gopherbot = "gopherbot"
+
+// fixWindow is how long after a CL is merged an issue that it fixes may be
+// closed and still be credited to it.
+const fixWindow = time.Hour
+
+// Issues returns the GitHub issues in the Go project that the user opened,
+// closed, commented on, triaged, or was @-mentioned on during r. If
+// repository is non-empty, only its issues are returned, and if username is
+// empty, all issues are. maintner does not record reactions, so they are not
+// counted.
+//
+// An issue is closed by whoever closed it last, unless that was gopherbot or
+// a commit closing it on behalf of a CL in fixes that was merged just
+// before, in which case it is credited to the user if the CL is theirs. The
+// user's accounts identify their CLs; they may be nil if username is empty.
+// If fixes is nil, closures are not credited to CLs.
+func Issues(corpus *maintner.Corpus, fixes Fixes, repository, username string, accounts *Accounts, r generic.Range) ([]*generic.Issue, error) {
 	issuesMap := make(map[*maintner.GitHubIssue]*generic.Issue)
 
-	if err := github.ForeachRepo(func(repo *maintner.GitHubRepo) error {
+	if err := corpus.GitHub().ForeachRepo(func(repo *maintner.GitHubRepo) error {
 		if repository != "" && repo.ID().Repo != repository {
 			return nil
 		}
@@ -34,27 +55,34 @@ func Issues(github *maintner.GitHub, repository, username string, start, end tim
 			}
 			// Check if the user opened the given issue.
 			if username == "" || (issue.User != nil && issue.User.Login == username) {
-				if inScope(issue.Created, start, end) {
+				if r.Contains(issue.Created) {
 					maybeAddIssue()
 
 					issuesMap[issue].OpenedBy = username
 					issuesMap[issue].DateOpened = issue.Created
 				}
 			}
-			// Check if the user closed the issue.
+			// Replay the issue's events to find who closed it last, since
+			// it may have been closed and reopened several times, and check
+			// if the user triaged the issue.
+			var (
+				last     *closure
+				reopened bool
+			)
 			if err := issue.ForeachEvent(func(event *maintner.GitHubIssueEvent) error {
+				switch event.Type {
+				case "closed":
+					last = newClosure(event, fixes[issue])
+					reopened = false
+				case "reopened":
+					last = nil
+					reopened = true
+				}
 				if username == "" || (event.Actor != nil && event.Actor.Login == username) {
-					if inScope(event.Created, start, end) {
-						switch event.Type {
-						case "closed":
+					if r.Contains(event.Created) {
+						if action, ok := generic.ToTriageAction(event.Type, eventDetail(event), event.Created); ok {
 							maybeAddIssue()
-							issuesMap[issue].DateClosed = issue.ClosedAt
-							issuesMap[issue].ClosedBy = issue.User.Login
-						case "reopened":
-							if _, ok := issuesMap[issue]; ok {
-								issuesMap[issue].DateClosed = time.Time{}
-								issuesMap[issue].ClosedBy = ""
-							}
+							issuesMap[issue].Triage = append(issuesMap[issue].Triage, action)
 						}
 					}
 				}
@@ -62,15 +90,50 @@ func Issues(github *maintner.GitHub, repository, username string, start, end tim
 			}); err != nil {
 				return err
 			}
-			return issue.ForeachComment(func(comment *maintner.GitHubComment) error {
-				if comment.User != nil && comment.User.Login == username {
-					if inScope(comment.Created, start, end) {
-						maybeAddIssue()
-						issuesMap[issue].Comments++
-					}
+			// Check if the user closed the issue.
+			if last != nil && r.Contains(last.at) {
+				if username == "" || last.closedBy(username, accounts) == username {
+					maybeAddIssue()
+				}
+			}
+			// Check if someone else mentioned the user when opening the
+			// issue or in a comment.
+			if issue.User != nil && issue.User.Login != username && r.Contains(issue.Created) && generic.MentionsUser(issue.Body, username) {
+				maybeAddIssue()
+				issuesMap[issue].Mentions++
+			}
+			if err := issue.ForeachComment(func(comment *maintner.GitHubComment) error {
+				if !r.Contains(comment.Created) {
+					return nil
+				}
+				switch {
+				case comment.User != nil && comment.User.Login == username:
+					maybeAddIssue()
+					issuesMap[issue].Comments++
+				case generic.MentionsUser(comment.Body, username):
+					maybeAddIssue()
+					issuesMap[issue].Mentions++
 				}
 				return nil
-			})
+			}); err != nil {
+				return err
+			}
+			// Without any "closed" events, keep the closure recorded on the
+			// issue itself.
+			if i, ok := issuesMap[issue]; ok {
+				switch {
+				case last != nil:
+					i.ClosedBy = last.closedBy(username, accounts)
+					i.DateClosed = last.at
+					if last.cl != nil {
+						i.ClosedByCL = link(last.cl)
+					}
+				case reopened:
+					i.ClosedBy = ""
+					i.DateClosed = time.Time{}
+				}
+			}
+			return nil
 		})
 	}); err != nil {
 		return nil, err
@@ -85,6 +148,113 @@ func Issues(github *maintner.GitHub, repository, username string, start, end tim
 	return issues, nil
 }
 
+// closure is how an issue was closed.
+type closure struct {
+	at time.Time
+	// actor is the GitHub login of whoever closed the issue.
+	actor string
+	// cl is the merged CL that closed the issue, if any.
+	cl *maintner.GerritCL
+}
+
+// newClosure returns the closure recorded by a "closed" event. Issues that
+// gopherbot or a commit closed are attributed to the last CL among fixes
+// that was merged within fixWindow before the issue was closed.
+func newClosure(event *maintner.GitHubIssueEvent, fixes []*maintner.GerritCL) *closure {
+	c := &closure{at: event.Created}
+	if event.Actor != nil {
+		c.actor = event.Actor.Login
+	}
+	if event.CommitID == "" && c.actor != gopherbot {
+		return c
+	}
+	var latest time.Time
+	for _, cl := range fixes {
+		merged := toMergeTime(cl)
+		if d := event.Created.Sub(merged); d < 0 || d > fixWindow {
+			continue
+		}
+		if c.cl == nil || merged.After(latest) {
+			c.cl, latest = cl, merged
+		}
+	}
+	return c
+}
+
+// closedBy returns the GitHub login of who closed the issue: the user, if
+// they wrote the CL that closed it, or else whoever closed it, such as
+// gopherbot.
+func (c *closure) closedBy(username string, accounts *Accounts) string {
+	if c.cl != nil && username != "" && accounts != nil && accounts.Emails[c.cl.Commit.Author.Email()] {
+		return username
+	}
+	return c.actor
+}
+
+// rxFixes matches the references to GitHub issues that close them when the
+// commit that contains them is merged, such as "Fixes #1234" or
+// "Closes golang/go#1234".
+var rxFixes = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s+(?:([\w.-]+)/([\w.-]+))?#(\d+)\b`)
+
+// Fixes are the merged CLs that fix each issue.
+type Fixes map[*maintner.GitHubIssue][]*maintner.GerritCL
+
+// IndexFixes returns the merged CLs that fix each issue, according to their
+// commit messages. A reference without a repository, such as "Fixes #1234",
+// refers to an issue in golang/go, and only from the main Go repository.
+//
+// Indexing scans every merged CL, so the index should be built once and
+// passed to each call to Issues.
+func IndexFixes(corpus *maintner.Corpus) (Fixes, error) {
+	fixes := make(Fixes)
+	err := corpus.Gerrit().ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
+		return project.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
+			if cl.Status != "merged" || cl.Commit == nil {
+				return nil
+			}
+			seen := make(map[*maintner.GitHubIssue]bool)
+			for _, m := range rxFixes.FindAllStringSubmatch(cl.Commit.Msg, -1) {
+				owner, repo := m[1], m[2]
+				if owner == "" {
+					if project.Project() != "go" {
+						continue
+					}
+					owner, repo = "golang", "go"
+				}
+				n, err := strconv.ParseInt(m[3], 10, 32)
+				if err != nil {
+					continue
+				}
+				ghRepo := corpus.GitHub().Repo(strings.ToLower(owner), strings.ToLower(repo))
+				if ghRepo == nil {
+					continue
+				}
+				issue := ghRepo.Issue(int32(n))
+				if issue == nil || seen[issue] {
+					continue
+				}
+				seen[issue] = true
+				fixes[issue] = append(fixes[issue], cl)
+			}
+			return nil
+		})
+	})
+	return fixes, err
+}
+
+// eventDetail returns the label, milestone, or assignee of an issue event.
+func eventDetail(event *maintner.GitHubIssueEvent) string {
+	switch {
+	case event.Label != "":
+		return event.Label
+	case event.Milestone != "":
+		return event.Milestone
+	case event.Assignee != nil:
+		return event.Assignee.Login
+	}
+	return ""
+}
+
 func GerritToGenericIssue(issue *maintner.GitHubIssue, repo *maintner.GitHubRepo) *generic.Issue {
 	var labels []string
 	for _, label := range issue.Labels {
@@ -0,0 +1,344 @@
+package golang_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+	"github.com/stamblerre/work-stats/golang"
+	"github.com/stamblerre/work-stats/internal/maintnertest"
+)
+
+func TestIssues(t *testing.T) {
+	fixes, err := golang.IndexFixes(corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hover := &generic.Issue{
+		Number:     100,
+		Link:       "github.com/golang/go/issues/100",
+		Repo:       "golang/go",
+		Title:      "x/tools/internal/lsp: hover is broken",
+		OpenedBy:   "alice",
+		ClosedBy:   "alice",
+		DateOpened: day(1),
+		DateClosed: day(4),
+		Labels:     []string{"gopls"},
+		Milestone:  "gopls/v0.8.2",
+	}
+	for _, tt := range []struct {
+		name       string
+		repo       string
+		username   string
+		start, end time.Time
+		want       []*generic.Issue
+	}{
+		{
+			name:     "all repos",
+			username: "alice",
+			start:    start,
+			end:      end,
+			want: []*generic.Issue{
+				hover,
+				{
+					Number:     101,
+					Link:       "github.com/golang/go/issues/101",
+					Repo:       "golang/go",
+					Title:      "cmd/go: build fails",
+					OpenedBy:   "bob",
+					DateOpened: day(3),
+					Comments:   2,
+					Mentions:   1,
+					Triage: []*generic.TriageAction{
+						{Kind: generic.Triaged, Detail: "NeedsInvestigation", Time: day(5)},
+						{Kind: generic.Milestoned, Detail: "Go1.19", Time: day(5)},
+						{Kind: generic.Assigned, Detail: "bob", Time: day(6)},
+					},
+				},
+				{
+					Number:     5,
+					Link:       "github.com/golang/vscode-go/issues/5",
+					Repo:       "golang/vscode-go",
+					Title:      "debug: breakpoints are ignored",
+					OpenedBy:   "alice",
+					DateOpened: day(10),
+				},
+			},
+		},
+		{
+			name:     "single repo",
+			repo:     "vscode-go",
+			username: "alice",
+			start:    start,
+			end:      end,
+			want: []*generic.Issue{{
+				Number:     5,
+				Link:       "github.com/golang/vscode-go/issues/5",
+				Repo:       "golang/vscode-go",
+				Title:      "debug: breakpoints are ignored",
+				OpenedBy:   "alice",
+				DateOpened: day(10),
+			}},
+		},
+		{
+			name:     "commenter",
+			repo:     "go",
+			username: "bob",
+			start:    start,
+			end:      end,
+			want: []*generic.Issue{
+				{
+					Number:     100,
+					Link:       "github.com/golang/go/issues/100",
+					Repo:       "golang/go",
+					Title:      "x/tools/internal/lsp: hover is broken",
+					OpenedBy:   "alice",
+					ClosedBy:   "alice",
+					DateOpened: day(1),
+					DateClosed: day(4),
+					Comments:   1,
+					Labels:     []string{"gopls"},
+					Milestone:  "gopls/v0.8.2",
+				},
+				{
+					Number:     101,
+					Link:       "github.com/golang/go/issues/101",
+					Repo:       "golang/go",
+					Title:      "cmd/go: build fails",
+					OpenedBy:   "bob",
+					DateOpened: day(3),
+					Comments:   1,
+					Mentions:   1,
+					Triage: []*generic.TriageAction{
+						{Kind: generic.Labeled, Detail: "GoCommand", Time: day(6)},
+					},
+				},
+			},
+		},
+		{
+			// Ranges include their start.
+			name:     "opened at start",
+			repo:     "vscode-go",
+			username: "alice",
+			start:    day(10),
+			end:      end,
+			want: []*generic.Issue{{
+				Number:     5,
+				Link:       "github.com/golang/vscode-go/issues/5",
+				Repo:       "golang/vscode-go",
+				Title:      "debug: breakpoints are ignored",
+				OpenedBy:   "alice",
+				DateOpened: day(10),
+			}},
+		},
+		{
+			// Ranges exclude their end.
+			name:     "opened at end",
+			repo:     "vscode-go",
+			username: "alice",
+			start:    start,
+			end:      day(10),
+		},
+		{
+			name:     "out of range",
+			repo:     "go",
+			username: "alice",
+			start:    start.AddDate(0, -3, 0),
+			end:      start.AddDate(0, -1, 0),
+			want: []*generic.Issue{{
+				Number:     103,
+				Link:       "github.com/golang/go/issues/103",
+				Repo:       "golang/go",
+				Title:      "cmd/go: old bug",
+				OpenedBy:   "alice",
+				DateOpened: day(1).AddDate(0, -2, 0),
+				Comments:   1,
+			}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := golang.Issues(corpus, fixes, tt.repo, tt.username, accounts(t, tt.username+"@golang.org"), generic.Range{Start: tt.start, End: tt.end})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected issues: %s", diff)
+			}
+		})
+	}
+}
+
+func TestIssuesClosedBy(t *testing.T) {
+	gopherbotGH := &maintnertest.GitHubUser{ID: 3, Login: "gopherbot"}
+	// fix returns a CL in the main Go repository with the given message,
+	// merged on the given day.
+	fix := func(number int32, author *maintnertest.GerritAccount, merged time.Time, msg string) *maintnertest.CL {
+		msg = fmt.Sprintf("%s\n\nChange-Id: I%040d\nReviewed-on: https://go-review.googlesource.com/c/go/+/%d\n", msg, number, number)
+		return &maintnertest.CL{
+			Project:   "go",
+			Number:    number,
+			Patchsets: []*maintnertest.Patchset{{Author: author, Time: merged.Add(-time.Hour), Msg: msg}},
+			Metas: []*maintnertest.Meta{
+				maintnertest.Upload(author, merged.Add(-time.Hour), 1),
+				maintnertest.Merge(author, merged, 1),
+			},
+		}
+	}
+	type closure struct {
+		ClosedBy, ClosedByCL string
+		DateClosed           time.Time
+	}
+	for _, tt := range []struct {
+		name   string
+		events []*maintnertest.Event
+		cls    []*maintnertest.CL
+		// want is nil if the issue is not reported, since alice neither
+		// closed nor triaged it during March.
+		want *closure
+	}{
+		{
+			name:   "closed by user",
+			events: []*maintnertest.Event{{Type: "closed", Actor: aliceGH, Created: day(2)}},
+			want:   &closure{ClosedBy: "alice", DateClosed: day(2)},
+		},
+		{
+			name:   "closed by someone else",
+			events: []*maintnertest.Event{{Type: "closed", Actor: bobGH, Created: day(2)}},
+		},
+		{
+			name:   "closed before the range",
+			events: []*maintnertest.Event{{Type: "closed", Actor: aliceGH, Created: day(2).AddDate(0, -1, 0)}},
+		},
+		{
+			name: "reopened",
+			events: []*maintnertest.Event{
+				{Type: "closed", Actor: aliceGH, Created: day(2)},
+				{Type: "reopened", Actor: bobGH, Created: day(3)},
+			},
+		},
+		{
+			name: "reopened and closed by user",
+			events: []*maintnertest.Event{
+				{Type: "closed", Actor: bobGH, Created: day(2)},
+				{Type: "reopened", Actor: aliceGH, Created: day(3)},
+				{Type: "closed", Actor: aliceGH, Created: day(4)},
+			},
+			want: &closure{ClosedBy: "alice", DateClosed: day(4)},
+		},
+		{
+			name: "reopened and closed by someone else",
+			events: []*maintnertest.Event{
+				{Type: "closed", Actor: aliceGH, Created: day(2)},
+				{Type: "reopened", Actor: bobGH, Created: day(3)},
+				{Type: "closed", Actor: bobGH, Created: day(4)},
+			},
+		},
+		{
+			name:   "closed by commit of user's CL",
+			events: []*maintnertest.Event{{Type: "closed", Actor: gopherbotGH, Created: day(2).Add(time.Minute), CommitID: "0123456789abcdef0123456789abcdef01234567"}},
+			cls:    []*maintnertest.CL{fix(2001, alice, day(2), "cmd/go: fix the build\n\nFixes #300")},
+			want:   &closure{ClosedBy: "alice", ClosedByCL: "go-review.googlesource.com/c/go/+/2001", DateClosed: day(2).Add(time.Minute)},
+		},
+		{
+			name:   "closed by gopherbot for user's CL in another repository",
+			events: []*maintnertest.Event{{Type: "closed", Actor: gopherbotGH, Created: day(2).Add(time.Minute)}},
+			cls: []*maintnertest.CL{{
+				Project:   "tools",
+				Number:    2002,
+				Patchsets: []*maintnertest.Patchset{{Author: alice, Time: day(1), Msg: "gopls: fix the build\n\nFixes golang/go#300\n\nChange-Id: I0000000000000000000000000000000000002002\n"}},
+				Metas: []*maintnertest.Meta{
+					maintnertest.Upload(alice, day(1), 1),
+					maintnertest.Merge(bob, day(2), 1),
+				},
+			}},
+			want: &closure{ClosedBy: "alice", ClosedByCL: "go-review.googlesource.com/c/tools/+/2002", DateClosed: day(2).Add(time.Minute)},
+		},
+		{
+			name:   "closed by someone else's CL",
+			events: []*maintnertest.Event{{Type: "closed", Actor: gopherbotGH, Created: day(2).Add(time.Minute)}},
+			cls:    []*maintnertest.CL{fix(2003, bob, day(2), "cmd/go: fix the build\n\nFixes #300")},
+		},
+		{
+			// Closures by someone else's CL are credited to gopherbot, like
+			// any other.
+			name: "triaged by user and closed by someone else's CL",
+			events: []*maintnertest.Event{
+				{Type: "labeled", Actor: aliceGH, Created: day(1), Label: "NeedsFix"},
+				{Type: "closed", Actor: gopherbotGH, Created: day(2).Add(time.Minute)},
+			},
+			cls:  []*maintnertest.CL{fix(2008, bob, day(2), "cmd/go: fix the build\n\nFixes #300")},
+			want: &closure{ClosedBy: "gopherbot", ClosedByCL: "go-review.googlesource.com/c/go/+/2008", DateClosed: day(2).Add(time.Minute)},
+		},
+		{
+			// A CL merged long before the issue was closed did not close
+			// it.
+			name:   "closed by gopherbot long after user's CL",
+			events: []*maintnertest.Event{{Type: "closed", Actor: gopherbotGH, Created: day(9)}},
+			cls:    []*maintnertest.CL{fix(2009, alice, day(2), "cmd/go: fix the build\n\nFixes #300")},
+		},
+		{
+			// Only CLs that fix the issue close it.
+			name:   "closed by gopherbot after a CL that updates the issue",
+			events: []*maintnertest.Event{{Type: "closed", Actor: gopherbotGH, Created: day(2).Add(time.Minute)}},
+			cls:    []*maintnertest.CL{fix(2004, alice, day(2), "cmd/go: start fixing the build\n\nUpdates #300")},
+		},
+		{
+			// People who close an issue by hand are credited, even if a CL
+			// fixed it.
+			name:   "closed by hand after user's CL",
+			events: []*maintnertest.Event{{Type: "closed", Actor: bobGH, Created: day(3)}},
+			cls:    []*maintnertest.CL{fix(2005, alice, day(2), "cmd/go: fix the build\n\nFixes #300")},
+		},
+		{
+			name: "reopened and closed by user's CL",
+			events: []*maintnertest.Event{
+				{Type: "closed", Actor: gopherbotGH, Created: day(2).Add(time.Minute)},
+				{Type: "reopened", Actor: aliceGH, Created: day(3)},
+				{Type: "closed", Actor: gopherbotGH, Created: day(5).Add(time.Minute)},
+			},
+			cls: []*maintnertest.CL{
+				fix(2006, bob, day(2), "cmd/go: fix the build\n\nFixes #300"),
+				fix(2007, alice, day(5), "cmd/go: really fix the build\n\nFixes #300"),
+			},
+			want: &closure{ClosedBy: "alice", ClosedByCL: "go-review.googlesource.com/c/go/+/2007", DateClosed: day(5).Add(time.Minute)},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := maintnertest.NewBuilder()
+			b.AddIssue(&maintnertest.Issue{
+				Number:  300,
+				Title:   "cmd/go: build fails",
+				User:    bobGH,
+				Created: day(1).AddDate(0, -2, 0),
+				Events:  tt.events,
+			})
+			b.AddCL(tt.cls...)
+			c, err := b.Corpus(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := golang.ResolveAccounts(c.Gerrit(), []string{"alice@golang.org"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fixes, err := golang.IndexFixes(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			issues, err := golang.Issues(c, fixes, "", "alice", a, generic.Range{Start: start, End: end})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got *closure
+			for _, issue := range issues {
+				got = &closure{ClosedBy: issue.ClosedBy, ClosedByCL: issue.ClosedByCL, DateClosed: issue.DateClosed}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected closure (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package golang
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stamblerre/work-stats/generic"
+	"golang.org/x/build/maintner"
+)
+
+// ReviewRequests returns the review requests the user received on Gerrit
+// during r, along with when they first responded to each.
+//
+// NoteDB only identifies reviewers by their Gerrit account ID, so the user's
+// account IDs must be resolved first. See ResolveAccounts.
+func ReviewRequests(gerrit *maintner.Gerrit, accounts *Accounts, r generic.Range) ([]*generic.ReviewRequest, error) {
+	if len(accounts.IDs) == 0 {
+		return nil, errors.New("unable to collect review requests, no Gerrit account ID was found for the user, so the reviewer ID cannot be matched")
+	}
+	var requests []*generic.ReviewRequest
+	if err := gerrit.ForeachProjectUnsorted(func(project *maintner.GerritProject) error {
+		return project.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
+			// Only count the first request for each reviewer on a CL.
+			requested := make(map[int]bool)
+			for _, meta := range cl.Metas {
+				for _, id := range addedReviewers(meta) {
+					email, ok := accounts.IDs[id]
+					if !ok || requested[id] {
+						continue
+					}
+					// Reviewers who add themselves weren't asked to review.
+					if personToID(meta.Commit.Author) == id {
+						continue
+					}
+					if !r.Contains(meta.Commit.CommitTime) {
+						continue
+					}
+					requested[id] = true
+					requests = append(requests, &generic.ReviewRequest{
+						Link:        link(cl),
+						Repo:        cl.Project.Project(),
+						Subject:     cl.Subject(),
+						Author:      cl.Owner().Email(),
+						Reviewer:    email,
+						Status:      toStatus(cl.Status),
+						RequestedAt: meta.Commit.CommitTime,
+						RespondedAt: firstResponse(cl, id, meta.Commit.CommitTime),
+					})
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].RequestedAt.Equal(requests[j].RequestedAt) {
+			return requests[i].RequestedAt.Before(requests[j].RequestedAt)
+		}
+		return requests[i].Link < requests[j].Link
+	})
+	return requests, nil
+}
+
+// addedReviewers returns the Gerrit account IDs of the reviewers added by
+// a meta commit, from its "Reviewer:" footers.
+func addedReviewers(meta *maintner.GerritMeta) []int {
+	var ids []int
+	for _, line := range strings.Split(meta.Footer(), "\n") {
+		if !strings.HasPrefix(line, "Reviewer: ") {
+			continue
+		}
+		// The value has the form "Gerrit User 1234 <1234@uuid>".
+		value := strings.TrimPrefix(line, "Reviewer: ")
+		if i := strings.Index(value, " <"); i >= 0 {
+			value = value[:i]
+		}
+		split := strings.Split(value, " ")
+		if len(split) != 3 || split[0] != "Gerrit" || split[1] != "User" {
+			continue
+		}
+		id, err := strconv.Atoi(split[2])
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// firstResponse returns the time of the reviewer's first message on the CL
+// after the given time, or the zero time if there is none.
+func firstResponse(cl *maintner.GerritCL, reviewer int, after time.Time) time.Time {
+	for _, msg := range cl.Messages {
+		if msg.Date.Before(after) {
+			continue
+		}
+		if personToID(msg.Author) == reviewer {
+			return msg.Date
+		}
+	}
+	return time.Time{}
+}
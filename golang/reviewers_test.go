@@ -0,0 +1,81 @@
+package golang_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stamblerre/work-stats/generic"
+	"github.com/stamblerre/work-stats/golang"
+)
+
+func TestReviewRequests(t *testing.T) {
+	for _, tt := range []struct {
+		emails     []string
+		start, end time.Time
+		want       []*generic.ReviewRequest
+		wantErr    bool
+	}{
+		{
+			emails: []string{"alice@golang.org"},
+			start:  start,
+			end:    end,
+			want: []*generic.ReviewRequest{
+				{
+					Link:        "go-review.googlesource.com/c/tools/+/1003",
+					Repo:        "tools",
+					Subject:     "gopls: update docs",
+					Author:      "bob@golang.org",
+					Reviewer:    "alice@golang.org",
+					Status:      generic.Merged,
+					RequestedAt: day(7).Add(time.Hour),
+					RespondedAt: day(8),
+				},
+				{
+					Link:        "go-review.googlesource.com/c/tools/+/1006",
+					Repo:        "tools",
+					Subject:     "gopls: add a setting",
+					Author:      "bob@golang.org",
+					Reviewer:    "alice@golang.org",
+					Status:      generic.New,
+					RequestedAt: day(12),
+				},
+			},
+		},
+		{
+			emails: []string{"bob@golang.org"},
+			start:  start,
+			end:    end,
+			want: []*generic.ReviewRequest{{
+				Link:        "go-review.googlesource.com/c/tools/+/1001",
+				Repo:        "tools",
+				Subject:     "internal/lsp: fix hover",
+				Author:      "alice@golang.org",
+				Reviewer:    "bob@golang.org",
+				Status:      generic.Merged,
+				RequestedAt: day(2).Add(time.Hour),
+				RespondedAt: day(3),
+			}},
+		},
+		{
+			emails: []string{"alice@golang.org"},
+			start:  day(20),
+			end:    end,
+		},
+		{
+			emails:  []string{"nobody@golang.org"},
+			start:   start,
+			end:     end,
+			wantErr: true,
+		},
+	} {
+		got, err := golang.ReviewRequests(corpus.Gerrit(), accounts(t, tt.emails...), generic.Range{Start: tt.start, End: tt.end})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got error %v, want error: %v", tt.emails, err, tt.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%v: unexpected review requests: %s", tt.emails, diff)
+		}
+	}
+}
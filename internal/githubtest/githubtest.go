@@ -0,0 +1,857 @@
+// Package githubtest provides an in-process fake of the parts of the GitHub
+// REST API used by work-stats.
+//
+// The fake serves issue search (with pagination and GitHub's 1000-result
+// cap), issue comments, events, timelines, and reactions, pull requests with
+// their merge status, reviews, and review comments, issue lookups that follow
+// transfers, and a GraphQL search for discussions. Every response carries
+// rate-limit headers, and the rate limit can be exhausted to test error
+// handling.
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/stamblerre/work-stats/generic"
+)
+
+// searchLimit is the maximum number of results GitHub returns for a search.
+const searchLimit = 1000
+
+// Issue is a GitHub issue or pull request served by the fake.
+type Issue struct {
+	Owner, Repo string
+	Number      int
+	Title       string
+	Body        string
+	User        string
+	Assignees   []string
+	Created     time.Time
+	// Updated defaults to the time of the latest activity on the issue.
+	Updated time.Time
+	// Indexed, if set, is the update time that searches filter on, in
+	// place of Updated, since GitHub's search index can be out of sync
+	// with its issues.
+	Indexed   time.Time
+	ClosedAt  time.Time
+	ClosedBy  string
+	Milestone string
+	Labels    []string
+	Comments  []*Comment
+	// Events are the issue's timeline events, such as "labeled".
+	Events    []*Event
+	Reactions []*Reaction
+
+	PullRequest    bool
+	Merged         bool
+	Reviews        []*Review
+	ReviewRequests []*ReviewRequest
+	// ReviewComments are comments on the pull request's diff.
+	ReviewComments []*Comment
+	// Pull request size statistics.
+	Commits      int
+	ChangedFiles int
+	Additions    int
+	Deletions    int
+
+	// TransferredTo is the "owner/repo" the issue was moved to, if any.
+	TransferredTo string
+}
+
+// Comment is a comment on an issue or pull request.
+type Comment struct {
+	User      string
+	Created   time.Time
+	Body      string
+	Reactions []*Reaction
+}
+
+// Reaction is a reaction, such as "+1", to an issue or comment.
+type Reaction struct {
+	User    string
+	Created time.Time
+	// Content defaults to "+1".
+	Content string
+}
+
+// Review is a pull request review.
+type Review struct {
+	User      string
+	Submitted time.Time
+	// State is "APPROVED", "CHANGES_REQUESTED", or "COMMENTED".
+	State string
+	Body  string
+}
+
+// Event is an issue timeline event, such as "labeled" or "assigned". Label,
+// Milestone, and Assignee are set according to the type of event.
+type Event struct {
+	Type      string
+	Actor     string
+	Created   time.Time
+	Label     string
+	Milestone string
+	Assignee  string
+}
+
+// ReviewRequest is a request for a user to review a pull request.
+type ReviewRequest struct {
+	Reviewer  string
+	Requester string
+	Created   time.Time
+}
+
+func (issue *Issue) updated() time.Time {
+	if !issue.Updated.IsZero() {
+		return issue.Updated
+	}
+	updated := issue.Created
+	if issue.ClosedAt.After(updated) {
+		updated = issue.ClosedAt
+	}
+	for _, c := range issue.Comments {
+		if c.Created.After(updated) {
+			updated = c.Created
+		}
+	}
+	for _, r := range issue.Reviews {
+		if r.Submitted.After(updated) {
+			updated = r.Submitted
+		}
+	}
+	for _, c := range issue.ReviewComments {
+		if c.Created.After(updated) {
+			updated = c.Created
+		}
+	}
+	for _, r := range issue.ReviewRequests {
+		if r.Created.After(updated) {
+			updated = r.Created
+		}
+	}
+	for _, e := range issue.Events {
+		if e.Created.After(updated) {
+			updated = e.Created
+		}
+	}
+	return updated
+}
+
+// reviewedBy reports whether the user has reviewed the pull request,
+// mirroring the "reviewed-by:" search qualifier.
+func (issue *Issue) reviewedBy(login string) bool {
+	for _, r := range issue.Reviews {
+		if r.User == login {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewRequested reports whether the user has been asked to review the
+// pull request and has not yet done so, mirroring the "review-requested:"
+// search qualifier.
+func (issue *Issue) reviewRequested(login string) bool {
+	if issue.reviewedBy(login) {
+		return false
+	}
+	for _, r := range issue.ReviewRequests {
+		if r.Reviewer == login {
+			return true
+		}
+	}
+	return false
+}
+
+// involves reports whether the user opened, closed, was assigned to,
+// commented on, was mentioned in, or reviewed the issue, mirroring the
+// "involves:" search qualifier.
+func (issue *Issue) involves(login string) bool {
+	if issue.User == login || issue.ClosedBy == login || generic.MentionsUser(issue.Body, login) {
+		return true
+	}
+	for _, a := range issue.Assignees {
+		if a == login {
+			return true
+		}
+	}
+	for _, c := range issue.Comments {
+		if c.User == login || generic.MentionsUser(c.Body, login) {
+			return true
+		}
+	}
+	for _, c := range issue.ReviewComments {
+		if c.User == login || generic.MentionsUser(c.Body, login) {
+			return true
+		}
+	}
+	for _, r := range issue.Reviews {
+		if r.User == login {
+			return true
+		}
+	}
+	return false
+}
+
+// Server is a fake GitHub API server.
+type Server struct {
+	server *httptest.Server
+
+	mu           sync.Mutex
+	issues       map[string]*Issue // keyed by "owner/repo#number"
+	commentsByID map[int64]*Comment
+	commentIDs   map[*Comment]int64
+	discussions  []*Discussion
+	remaining    int            // -1 for unlimited
+	requests     map[string]int // request counts by endpoint
+}
+
+// NewServer starts a fake GitHub API server. Callers must call Close when
+// they are done with it.
+func NewServer() *Server {
+	s := &Server{
+		issues:       make(map[string]*Issue),
+		commentsByID: make(map[int64]*Comment),
+		commentIDs:   make(map[*Comment]int64),
+		remaining:    -1,
+		requests:     make(map[string]int),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a GitHub client that talks to the server.
+func (s *Server) Client() *github.Client {
+	client := github.NewClient(s.server.Client())
+	u, err := url.Parse(s.server.URL + "/")
+	if err != nil {
+		panic(err)
+	}
+	client.BaseURL = u
+	return client
+}
+
+// AddIssue adds issues or pull requests to the server.
+func (s *Server) AddIssue(issues ...*Issue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, issue := range issues {
+		s.issues[issueKey(issue.Owner, issue.Repo, issue.Number)] = issue
+		// Comments are numbered across all issues, as on GitHub, so that
+		// their reactions can be looked up by ID alone.
+		for _, c := range append(issue.Comments, issue.ReviewComments...) {
+			id := int64(len(s.commentsByID) + 1)
+			s.commentsByID[id] = c
+			s.commentIDs[c] = id
+		}
+	}
+}
+
+// SetRateLimit sets the number of requests the server will answer before
+// responding with rate limit errors. A negative value removes the limit.
+func (s *Server) SetRateLimit(remaining int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remaining = remaining
+}
+
+// Requests returns the number of requests made to the given endpoint, which
+// is one of "search", "issue", "comments", "events", "timeline", "reactions",
+// "pull", "merged", "reviews", "review-comments", or "graphql".
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+func issueKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reset := time.Now().Add(time.Hour).Unix()
+	w.Header().Set("X-RateLimit-Limit", "5000")
+	w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+	switch {
+	case s.remaining == 0:
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		writeError(w, http.StatusForbidden, "API rate limit exceeded for "+r.RemoteAddr+".")
+		return
+	case s.remaining > 0:
+		s.remaining--
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(s.remaining))
+	default:
+		w.Header().Set("X-RateLimit-Remaining", "5000")
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "graphql" && r.Method == http.MethodPost {
+		s.requests["graphql"]++
+		s.graphql(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	switch {
+	case len(parts) == 2 && parts[0] == "search" && parts[1] == "issues":
+		s.requests["search"]++
+		s.search(w, r)
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "issues":
+		s.requests["issue"]++
+		s.issue(w, r, parts[1], parts[2], parts[4])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && parts[5] == "comments":
+		s.requests["comments"]++
+		s.comments(w, r, parts[1], parts[2], parts[4])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && parts[5] == "events":
+		s.requests["events"]++
+		s.events(w, r, parts[1], parts[2], parts[4])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && parts[5] == "timeline":
+		s.requests["timeline"]++
+		s.timeline(w, r, parts[1], parts[2], parts[4])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && parts[5] == "reactions":
+		s.requests["reactions"]++
+		if issue := s.lookup(parts[1], parts[2], parts[4]); issue != nil {
+			s.reactions(w, r, issue.Reactions)
+		} else {
+			writeError(w, http.StatusNotFound, "Not Found")
+		}
+	case len(parts) == 7 && parts[0] == "repos" && (parts[3] == "issues" || parts[3] == "pulls") && parts[4] == "comments" && parts[6] == "reactions":
+		s.requests["reactions"]++
+		id, _ := strconv.ParseInt(parts[5], 10, 64)
+		if c, ok := s.commentsByID[id]; ok {
+			s.reactions(w, r, c.Reactions)
+		} else {
+			writeError(w, http.StatusNotFound, "Not Found")
+		}
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "pulls":
+		s.requests["pull"]++
+		s.pull(w, r, parts[1], parts[2], parts[4])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "pulls" && parts[5] == "merge":
+		s.requests["merged"]++
+		s.merged(w, r, parts[1], parts[2], parts[4])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "pulls" && parts[5] == "reviews":
+		s.requests["reviews"]++
+		s.reviews(w, r, parts[1], parts[2], parts[4])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "pulls" && parts[5] == "comments":
+		s.requests["review-comments"]++
+		s.reviewComments(w, r, parts[1], parts[2], parts[4])
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (s *Server) lookup(owner, repo, number string) *Issue {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return nil
+	}
+	return s.issues[issueKey(owner, repo, n)]
+}
+
+// search implements GET /search/issues. It understands the "involves:",
+// "reviewed-by:", "review-requested:", "updated:", "is:", and "repo:"
+// qualifiers.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	var matches []*Issue
+	terms := strings.Fields(r.URL.Query().Get("q"))
+	for _, issue := range s.issues {
+		if issue.TransferredTo != "" {
+			continue
+		}
+		ok, err := matchesQuery(issue, terms)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if ok {
+			matches = append(matches, issue)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		ui, uj := matches[i].updated(), matches[j].updated()
+		if !ui.Equal(uj) {
+			return ui.Before(uj)
+		}
+		return issueKey(matches[i].Owner, matches[i].Repo, matches[i].Number) < issueKey(matches[j].Owner, matches[j].Repo, matches[j].Number)
+	})
+	if r.URL.Query().Get("order") == "desc" {
+		for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+			matches[i], matches[j] = matches[j], matches[i]
+		}
+	}
+	page, perPage := pagination(r)
+	if page*perPage > searchLimit {
+		writeError(w, http.StatusUnprocessableEntity, "Only the first 1000 search results are available")
+		return
+	}
+	total := len(matches)
+	items := []github.Issue{}
+	for _, issue := range paginate(matches, page, perPage) {
+		items = append(items, *s.toGitHub(issue))
+	}
+	setLink(w, r, page, perPage, min(total, searchLimit))
+	writeJSON(w, &github.IssuesSearchResult{
+		Total:             github.Int(total),
+		IncompleteResults: github.Bool(false),
+		Issues:            items,
+	})
+}
+
+func matchesQuery(issue *Issue, terms []string) (bool, error) {
+	for _, term := range terms {
+		i := strings.Index(term, ":")
+		if i < 0 {
+			if !strings.Contains(strings.ToLower(issue.Title), strings.ToLower(term)) {
+				return false, nil
+			}
+			continue
+		}
+		key, value := term[:i], term[i+1:]
+		switch key {
+		case "involves":
+			if !issue.involves(value) {
+				return false, nil
+			}
+		case "reviewed-by":
+			if !issue.PullRequest || !issue.reviewedBy(value) {
+				return false, nil
+			}
+		case "review-requested":
+			if !issue.PullRequest || !issue.reviewRequested(value) {
+				return false, nil
+			}
+		case "is":
+			switch value {
+			case "issue":
+				if issue.PullRequest {
+					return false, nil
+				}
+			case "pr":
+				if !issue.PullRequest {
+					return false, nil
+				}
+			case "open":
+				if !issue.ClosedAt.IsZero() {
+					return false, nil
+				}
+			case "closed":
+				if issue.ClosedAt.IsZero() {
+					return false, nil
+				}
+			}
+		case "repo":
+			if value != issue.Owner+"/"+issue.Repo {
+				return false, nil
+			}
+		case "updated":
+			from, to, err := parseRange(value)
+			if err != nil {
+				return false, err
+			}
+			updated := issue.updated()
+			if !issue.Indexed.IsZero() {
+				updated = issue.Indexed
+			}
+			if updated.Before(from) || updated.After(to) {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("unsupported qualifier %q", key)
+		}
+	}
+	return true, nil
+}
+
+// parseRange parses an inclusive range of the form "from..to".
+func parseRange(s string) (from, to time.Time, err error) {
+	split := strings.SplitN(s, "..", 2)
+	if len(split) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range %q", s)
+	}
+	if from, err = time.Parse(time.RFC3339, split[0]); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if to, err = time.Parse(time.RFC3339, split[1]); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+// issue implements GET /repos/{owner}/{repo}/issues/{number}, redirecting
+// requests for transferred issues to their new location.
+func (s *Server) issue(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if issue.TransferredTo != "" {
+		http.Redirect(w, r, fmt.Sprintf("/repos/%s/issues/%d", issue.TransferredTo, issue.Number), http.StatusMovedPermanently)
+		return
+	}
+	writeJSON(w, s.toGitHub(issue))
+}
+
+// comments implements GET /repos/{owner}/{repo}/issues/{number}/comments.
+func (s *Server) comments(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	matches, err := since(r, issue.Comments)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	page, perPage := pagination(r)
+	comments := []*github.IssueComment{}
+	for _, c := range paginate(matches, page, perPage) {
+		created := c.Created
+		comments = append(comments, &github.IssueComment{
+			ID:        github.Int64(s.commentIDs[c]),
+			Body:      github.String(c.Body),
+			User:      &github.User{Login: github.String(c.User)},
+			Reactions: &github.Reactions{TotalCount: github.Int(len(c.Reactions))},
+			CreatedAt: &created,
+			UpdatedAt: &created,
+		})
+	}
+	setLink(w, r, page, perPage, len(matches))
+	writeJSON(w, comments)
+}
+
+// reviewComments implements GET /repos/{owner}/{repo}/pulls/{number}/comments.
+func (s *Server) reviewComments(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil || !issue.PullRequest {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	matches, err := since(r, issue.ReviewComments)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	page, perPage := pagination(r)
+	comments := []*github.PullRequestComment{}
+	for _, c := range paginate(matches, page, perPage) {
+		created := c.Created
+		comments = append(comments, &github.PullRequestComment{
+			ID:        github.Int64(s.commentIDs[c]),
+			Body:      github.String(c.Body),
+			User:      &github.User{Login: github.String(c.User)},
+			Reactions: &github.Reactions{TotalCount: github.Int(len(c.Reactions))},
+			CreatedAt: &created,
+			UpdatedAt: &created,
+		})
+	}
+	setLink(w, r, page, perPage, len(matches))
+	writeJSON(w, comments)
+}
+
+// since returns the comments created at or after the time in the request's
+// "since" parameter, if any. Comments are never edited, so this is the same
+// as filtering by when they were last updated, as GitHub does.
+func since(r *http.Request, comments []*Comment) ([]*Comment, error) {
+	v := r.URL.Query().Get("since")
+	if v == "" {
+		return comments, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Comment
+	for _, c := range comments {
+		if c.Created.Before(t) {
+			continue
+		}
+		matches = append(matches, c)
+	}
+	return matches, nil
+}
+
+// reactions implements GET /repos/{owner}/{repo}/issues/{number}/reactions,
+// and the equivalent endpoints for issue and review comments.
+func (s *Server) reactions(w http.ResponseWriter, r *http.Request, reactions []*Reaction) {
+	page, perPage := pagination(r)
+	// go-github's Reaction lacks the created_at field, so reactions are
+	// written by hand.
+	type reaction struct {
+		ID        int64        `json:"id"`
+		User      *github.User `json:"user"`
+		Content   string       `json:"content"`
+		CreatedAt time.Time    `json:"created_at"`
+	}
+	result := []*reaction{}
+	for i, re := range paginate(reactions, page, perPage) {
+		content := re.Content
+		if content == "" {
+			content = "+1"
+		}
+		result = append(result, &reaction{
+			ID:        int64((page-1)*perPage + i + 1),
+			User:      &github.User{Login: github.String(re.User)},
+			Content:   content,
+			CreatedAt: re.Created,
+		})
+	}
+	setLink(w, r, page, perPage, len(reactions))
+	writeJSON(w, result)
+}
+
+// events implements GET /repos/{owner}/{repo}/issues/{number}/events. Only
+// "review_requested" events are recorded.
+func (s *Server) events(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	page, perPage := pagination(r)
+	// go-github's IssueEvent lacks the requested_reviewer field, so the
+	// events are written by hand.
+	type event struct {
+		ID                int64        `json:"id"`
+		Event             string       `json:"event"`
+		Actor             *github.User `json:"actor"`
+		CreatedAt         time.Time    `json:"created_at"`
+		RequestedReviewer *github.User `json:"requested_reviewer"`
+	}
+	events := []*event{}
+	for i, req := range paginate(issue.ReviewRequests, page, perPage) {
+		events = append(events, &event{
+			ID:                int64((page-1)*perPage + i + 1),
+			Event:             "review_requested",
+			Actor:             &github.User{Login: github.String(req.Requester)},
+			CreatedAt:         req.Created,
+			RequestedReviewer: &github.User{Login: github.String(req.Reviewer)},
+		})
+	}
+	setLink(w, r, page, perPage, len(issue.ReviewRequests))
+	writeJSON(w, events)
+}
+
+// timeline implements GET /repos/{owner}/{repo}/issues/{number}/timeline.
+// Only the issue's Events are recorded.
+func (s *Server) timeline(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	page, perPage := pagination(r)
+	timeline := []*github.Timeline{}
+	for i, e := range paginate(issue.Events, page, perPage) {
+		created := e.Created
+		t := &github.Timeline{
+			ID:        github.Int64(int64((page-1)*perPage + i + 1)),
+			Event:     github.String(e.Type),
+			Actor:     &github.User{Login: github.String(e.Actor)},
+			CreatedAt: &created,
+		}
+		if e.Label != "" {
+			t.Label = &github.Label{Name: github.String(e.Label)}
+		}
+		if e.Milestone != "" {
+			t.Milestone = &github.Milestone{Title: github.String(e.Milestone)}
+		}
+		if e.Assignee != "" {
+			t.Assignee = &github.User{Login: github.String(e.Assignee)}
+		}
+		timeline = append(timeline, t)
+	}
+	setLink(w, r, page, perPage, len(issue.Events))
+	writeJSON(w, timeline)
+}
+
+// pull implements GET /repos/{owner}/{repo}/pulls/{number}.
+func (s *Server) pull(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil || !issue.PullRequest {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	i := s.toGitHub(issue)
+	writeJSON(w, &github.PullRequest{
+		Number:         i.Number,
+		Title:          i.Title,
+		Body:           i.Body,
+		User:           i.User,
+		State:          i.State,
+		CreatedAt:      i.CreatedAt,
+		UpdatedAt:      i.UpdatedAt,
+		ClosedAt:       i.ClosedAt,
+		HTMLURL:        i.HTMLURL,
+		Merged:         github.Bool(issue.Merged),
+		Commits:        github.Int(issue.Commits),
+		ChangedFiles:   github.Int(issue.ChangedFiles),
+		Additions:      github.Int(issue.Additions),
+		Deletions:      github.Int(issue.Deletions),
+		Comments:       i.Comments,
+		ReviewComments: github.Int(len(issue.ReviewComments)),
+	})
+}
+
+// merged implements GET /repos/{owner}/{repo}/pulls/{number}/merge.
+func (s *Server) merged(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil || !issue.PullRequest || !issue.Merged {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reviews implements GET /repos/{owner}/{repo}/pulls/{number}/reviews.
+func (s *Server) reviews(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	issue := s.lookup(owner, repo, number)
+	if issue == nil || !issue.PullRequest {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	page, perPage := pagination(r)
+	reviews := []*github.PullRequestReview{}
+	for i, review := range paginate(issue.Reviews, page, perPage) {
+		submitted := review.Submitted
+		reviews = append(reviews, &github.PullRequestReview{
+			ID:          github.Int64(int64((page-1)*perPage + i + 1)),
+			User:        &github.User{Login: github.String(review.User)},
+			Body:        github.String(review.Body),
+			State:       github.String(review.State),
+			SubmittedAt: &submitted,
+		})
+	}
+	setLink(w, r, page, perPage, len(issue.Reviews))
+	writeJSON(w, reviews)
+}
+
+func (s *Server) toGitHub(issue *Issue) *github.Issue {
+	kind := "issues"
+	if issue.PullRequest {
+		kind = "pull"
+	}
+	created, updated := issue.Created, issue.updated()
+	result := &github.Issue{
+		Number:        github.Int(issue.Number),
+		Title:         github.String(issue.Title),
+		Body:          github.String(issue.Body),
+		User:          &github.User{Login: github.String(issue.User)},
+		State:         github.String("open"),
+		Comments:      github.Int(len(issue.Comments)),
+		Reactions:     &github.Reactions{TotalCount: github.Int(len(issue.Reactions))},
+		CreatedAt:     &created,
+		UpdatedAt:     &updated,
+		HTMLURL:       github.String(fmt.Sprintf("https://github.com/%s/%s/%s/%d", issue.Owner, issue.Repo, kind, issue.Number)),
+		RepositoryURL: github.String(fmt.Sprintf("https://api.github.com/repos/%s/%s", issue.Owner, issue.Repo)),
+	}
+	if !issue.ClosedAt.IsZero() {
+		closed := issue.ClosedAt
+		result.ClosedAt = &closed
+		result.State = github.String("closed")
+	}
+	if issue.ClosedBy != "" {
+		result.ClosedBy = &github.User{Login: github.String(issue.ClosedBy)}
+	}
+	if issue.Milestone != "" {
+		result.Milestone = &github.Milestone{Title: github.String(issue.Milestone)}
+	}
+	for _, label := range issue.Labels {
+		result.Labels = append(result.Labels, github.Label{Name: github.String(label)})
+	}
+	for _, a := range issue.Assignees {
+		result.Assignees = append(result.Assignees, &github.User{Login: github.String(a)})
+	}
+	if issue.PullRequest {
+		result.PullRequestLinks = &github.PullRequestLinks{
+			HTMLURL: result.HTMLURL,
+		}
+	}
+	return result
+}
+
+// pagination returns the requested page and page size, applying GitHub's
+// defaults.
+func pagination(r *http.Request) (page, perPage int) {
+	page, perPage = 1, 30
+	if v, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && v > 0 {
+		page = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && v > 0 {
+		perPage = v
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+	return page, perPage
+}
+
+func paginate[T any](s []T, page, perPage int) []T {
+	lo := (page - 1) * perPage
+	if lo >= len(s) {
+		return nil
+	}
+	hi := lo + perPage
+	if hi > len(s) {
+		hi = len(s)
+	}
+	return s[lo:hi]
+}
+
+// setLink sets the Link header used by clients to find the next page.
+func setLink(w http.ResponseWriter, r *http.Request, page, perPage, total int) {
+	last := (total + perPage - 1) / perPage
+	if page >= last {
+		return
+	}
+	link := func(p int) string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", fmt.Sprint(p))
+		q.Set("per_page", fmt.Sprint(perPage))
+		u.RawQuery = q.Encode()
+		return fmt.Sprintf("<http://%s%s>", r.Host, u.RequestURI())
+	}
+	w.Header().Set("Link", fmt.Sprintf(`%s; rel="next", %s; rel="last"`, link(page+1), link(last)))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,247 @@
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stamblerre/work-stats/generic"
+)
+
+// Discussion is a GitHub Discussions thread served by the fake.
+type Discussion struct {
+	Owner, Repo string
+	Number      int
+	Title       string
+	Body        string
+	User        string
+	Category    string
+	Created     time.Time
+	Comments    []*DiscussionComment
+}
+
+// DiscussionComment is a top-level comment on a discussion.
+type DiscussionComment struct {
+	User    string
+	Created time.Time
+	Body    string
+	Replies []*Comment
+	// Answer marks the comment as the discussion's answer.
+	Answer bool
+}
+
+func (d *Discussion) updated() time.Time {
+	updated := d.Created
+	for _, c := range d.Comments {
+		if c.Created.After(updated) {
+			updated = c.Created
+		}
+		for _, reply := range c.Replies {
+			if reply.Created.After(updated) {
+				updated = reply.Created
+			}
+		}
+	}
+	return updated
+}
+
+// involves reports whether the user started, commented on, or was mentioned
+// in the discussion, mirroring the "involves:" search qualifier.
+func (d *Discussion) involves(login string) bool {
+	if d.User == login || generic.MentionsUser(d.Body, login) {
+		return true
+	}
+	for _, c := range d.Comments {
+		if c.User == login || generic.MentionsUser(c.Body, login) {
+			return true
+		}
+		for _, reply := range c.Replies {
+			if reply.User == login || generic.MentionsUser(reply.Body, login) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddDiscussion adds discussions to the server.
+func (s *Server) AddDiscussion(discussions ...*Discussion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discussions = append(s.discussions, discussions...)
+}
+
+// graphql implements POST /graphql for discussion searches, that is, queries
+// whose search has "type: DISCUSSION". The search query is read from the "q"
+// variable and understands the "involves:", "updated:", and "repo:"
+// qualifiers. Results are paginated by the "first" and "after" variables.
+// Every other field of the query is ignored, and the response includes all
+// of the fields that work-stats asks for.
+func (s *Server) graphql(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string `json:"query"`
+		Variables struct {
+			Q     string  `json:"q"`
+			First int     `json:"first"`
+			After *string `json:"after"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	if !strings.Contains(req.Query, "type: DISCUSSION") {
+		writeGraphQLError(w, "only discussion searches are supported")
+		return
+	}
+	var matches []*Discussion
+	for _, d := range s.discussions {
+		ok, err := d.matchesQuery(strings.Fields(req.Variables.Q))
+		if err != nil {
+			writeGraphQLError(w, err.Error())
+			return
+		}
+		if ok {
+			matches = append(matches, d)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return issueKey(matches[i].Owner, matches[i].Repo, matches[i].Number) < issueKey(matches[j].Owner, matches[j].Repo, matches[j].Number)
+	})
+
+	// Cursors are the offset of the last result on the page.
+	offset := 0
+	if req.Variables.After != nil {
+		n, err := strconv.Atoi(*req.Variables.After)
+		if err != nil {
+			writeGraphQLError(w, fmt.Sprintf("invalid cursor %q", *req.Variables.After))
+			return
+		}
+		offset = n
+	}
+	first := req.Variables.First
+	if first <= 0 || first > 100 {
+		first = 100
+	}
+	end := min(offset+first, len(matches))
+	if offset > end {
+		offset = end
+	}
+
+	type author struct {
+		Login string `json:"login"`
+	}
+	type reply struct {
+		Author    author    `json:"author"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	type comment struct {
+		Author    author    `json:"author"`
+		CreatedAt time.Time `json:"createdAt"`
+		Replies   struct {
+			Nodes []reply `json:"nodes"`
+		} `json:"replies"`
+	}
+	type discussion struct {
+		Number    int       `json:"number"`
+		Title     string    `json:"title"`
+		URL       string    `json:"url"`
+		CreatedAt time.Time `json:"createdAt"`
+		Author    author    `json:"author"`
+		Category  struct {
+			Name string `json:"name"`
+		} `json:"category"`
+		Repository struct {
+			NameWithOwner string `json:"nameWithOwner"`
+		} `json:"repository"`
+		Answer   *reply `json:"answer"`
+		Comments struct {
+			Nodes []comment `json:"nodes"`
+		} `json:"comments"`
+	}
+	nodes := []discussion{}
+	for _, d := range matches[offset:end] {
+		n := discussion{
+			Number:    d.Number,
+			Title:     d.Title,
+			URL:       fmt.Sprintf("https://github.com/%s/%s/discussions/%d", d.Owner, d.Repo, d.Number),
+			CreatedAt: d.Created,
+			Author:    author{d.User},
+		}
+		n.Category.Name = d.Category
+		n.Repository.NameWithOwner = d.Owner + "/" + d.Repo
+		n.Comments.Nodes = []comment{}
+		for _, c := range d.Comments {
+			nc := comment{Author: author{c.User}, CreatedAt: c.Created}
+			nc.Replies.Nodes = []reply{}
+			for _, r := range c.Replies {
+				nc.Replies.Nodes = append(nc.Replies.Nodes, reply{Author: author{r.User}, CreatedAt: r.Created})
+			}
+			n.Comments.Nodes = append(n.Comments.Nodes, nc)
+			if c.Answer {
+				n.Answer = &reply{Author: author{c.User}, CreatedAt: c.Created}
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	var result struct {
+		Data struct {
+			Search struct {
+				DiscussionCount int `json:"discussionCount"`
+				PageInfo        struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []discussion `json:"nodes"`
+			} `json:"search"`
+		} `json:"data"`
+	}
+	result.Data.Search.DiscussionCount = len(matches)
+	result.Data.Search.PageInfo.HasNextPage = end < len(matches)
+	result.Data.Search.PageInfo.EndCursor = strconv.Itoa(end)
+	result.Data.Search.Nodes = nodes
+	writeJSON(w, &result)
+}
+
+func (d *Discussion) matchesQuery(terms []string) (bool, error) {
+	for _, term := range terms {
+		i := strings.Index(term, ":")
+		if i < 0 {
+			return false, fmt.Errorf("unsupported search term %q", term)
+		}
+		key, value := term[:i], term[i+1:]
+		switch key {
+		case "involves":
+			if !d.involves(value) {
+				return false, nil
+			}
+		case "repo":
+			if value != d.Owner+"/"+d.Repo {
+				return false, nil
+			}
+		case "updated":
+			from, to, err := parseRange(value)
+			if err != nil {
+				return false, err
+			}
+			if updated := d.updated(); updated.Before(from) || updated.After(to) {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("unsupported qualifier %q", key)
+		}
+	}
+	return true, nil
+}
+
+// writeGraphQLError writes a GraphQL error, which GitHub reports with a 200
+// status.
+func writeGraphQLError(w http.ResponseWriter, message string) {
+	writeJSON(w, map[string]interface{}{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
@@ -0,0 +1,530 @@
+// Package maintnertest builds synthetic maintner corpora for tests.
+//
+// A Builder accumulates Gerrit CLs and GitHub issues, renders them as the
+// same mutation log that maintnerd would produce, and replays that log into
+// a fresh maintner.Corpus. This keeps tests hermetic: nothing is downloaded
+// and the data never changes underneath the test.
+package maintnertest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/build/maintner"
+	"golang.org/x/build/maintner/maintpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ServerUUID is the Gerrit server UUID used in the emails of meta commit
+// authors, for example "Gerrit User 1234 <1234@ServerUUID>".
+const ServerUUID = "62eb7196-b449-3ce5-99f1-c037f21e1705"
+
+// emptyTree is the hash of the empty git tree. The tree hash is required by
+// maintner's commit parser but is otherwise unused.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// GerritAccount is a Gerrit user.
+type GerritAccount struct {
+	ID    int
+	Name  string
+	Email string
+}
+
+// metaPerson is how the account appears as the author of NoteDB meta commits.
+func (a *GerritAccount) metaPerson() string {
+	return fmt.Sprintf("Gerrit User %d <%d@%s>", a.ID, a.ID, ServerUUID)
+}
+
+// gitPerson is how the account appears as the author of a patch set.
+func (a *GerritAccount) gitPerson() string {
+	return fmt.Sprintf("%s <%s>", a.Name, a.Email)
+}
+
+// CL describes a Gerrit change.
+type CL struct {
+	// Project is the Gerrit project on go.googlesource.com, such as "tools".
+	Project string
+	Number  int32
+	// Branch defaults to "master".
+	Branch string
+	// Patchsets are the uploaded versions of the CL, oldest first.
+	// The author of the first patch set is the CL's owner.
+	Patchsets []*Patchset
+	// Metas are the NoteDB meta commits, oldest first. The author of the
+	// first meta commit determines the CL's owner ID.
+	Metas []*Meta
+}
+
+// Patchset is a single uploaded version of a CL.
+type Patchset struct {
+	Author *GerritAccount
+	Time   time.Time
+	Msg    string
+	Files  []*File
+}
+
+// File is a file modified by a patch set.
+type File struct {
+	Name           string
+	Added, Deleted int64
+}
+
+// Meta is a NoteDB meta commit.
+type Meta struct {
+	Author  *GerritAccount
+	Time    time.Time
+	Subject string
+	// Message is the review message, such as "Patch Set 1: Code-Review+2".
+	// It is omitted if empty.
+	Message string
+	// Footers are the "Key: value" lines at the end of the commit.
+	Footers []string
+}
+
+func (m *Meta) msg() string {
+	var b strings.Builder
+	b.WriteString(m.Subject)
+	b.WriteString("\n\n")
+	if m.Message != "" {
+		b.WriteString(m.Message)
+		b.WriteString("\n\n")
+	}
+	for _, f := range m.Footers {
+		b.WriteString(f)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Upload returns the meta commit recorded when who uploads the given patch set.
+func Upload(who *GerritAccount, t time.Time, patchset int) *Meta {
+	subject := "Create change"
+	if patchset > 1 {
+		subject = fmt.Sprintf("Create patch set %d", patchset)
+	}
+	return &Meta{
+		Author:  who,
+		Time:    t,
+		Subject: subject,
+		Message: fmt.Sprintf("Uploaded patch set %d.", patchset),
+		Footers: []string{
+			fmt.Sprintf("Patch-set: %d", patchset),
+			"Tag: autogenerated:gerrit:newPatchSet",
+		},
+	}
+}
+
+// Reply returns the meta commit recorded when who replies to the given patch
+// set. Votes are of the form "Code-Review+2".
+func Reply(who *GerritAccount, t time.Time, patchset int, message string, votes ...string) *Meta {
+	header := fmt.Sprintf("Patch Set %d:", patchset)
+	if len(votes) > 0 {
+		header += " " + strings.Join(votes, " ")
+	}
+	if message != "" {
+		header += "\n\n" + message
+	}
+	footers := []string{fmt.Sprintf("Patch-set: %d", patchset)}
+	for _, vote := range votes {
+		// Label names may contain dashes, such as "Code-Review".
+		i := strings.LastIndexAny(vote, "+-")
+		if i < 0 {
+			continue
+		}
+		footers = append(footers, fmt.Sprintf("Label: %s=%s", vote[:i], vote[i:]))
+	}
+	return &Meta{
+		Author:  who,
+		Time:    t,
+		Subject: fmt.Sprintf("Update patch set %d", patchset),
+		Message: header,
+		Footers: footers,
+	}
+}
+
+// AddReviewers returns the meta commit recorded when who adds reviewers to
+// the CL.
+func AddReviewers(who *GerritAccount, t time.Time, patchset int, reviewers ...*GerritAccount) *Meta {
+	footers := []string{fmt.Sprintf("Patch-set: %d", patchset)}
+	for _, r := range reviewers {
+		footers = append(footers, "Reviewer: "+r.metaPerson())
+	}
+	return &Meta{
+		Author:  who,
+		Time:    t,
+		Subject: fmt.Sprintf("Update patch set %d", patchset),
+		Footers: footers,
+	}
+}
+
+// Merge returns the meta commit recorded when who submits the given patch set.
+func Merge(who *GerritAccount, t time.Time, patchset int) *Meta {
+	return &Meta{
+		Author:  who,
+		Time:    t,
+		Subject: fmt.Sprintf("Update patch set %d", patchset),
+		Message: "Change has been successfully cherry-picked.",
+		Footers: []string{
+			fmt.Sprintf("Patch-set: %d", patchset),
+			"Status: merged",
+			"Tag: autogenerated:gerrit:merged",
+		},
+	}
+}
+
+// Abandon returns the meta commit recorded when who abandons the CL.
+func Abandon(who *GerritAccount, t time.Time, patchset int) *Meta {
+	return &Meta{
+		Author:  who,
+		Time:    t,
+		Subject: fmt.Sprintf("Update patch set %d", patchset),
+		Message: "Abandoned",
+		Footers: []string{
+			fmt.Sprintf("Patch-set: %d", patchset),
+			"Status: abandoned",
+			"Tag: autogenerated:gerrit:abandon",
+		},
+	}
+}
+
+// WorkInProgress returns the meta commit recorded when who marks the CL as
+// a work in progress.
+func WorkInProgress(who *GerritAccount, t time.Time, patchset int) *Meta {
+	return &Meta{
+		Author:  who,
+		Time:    t,
+		Subject: fmt.Sprintf("Update patch set %d", patchset),
+		Message: "Set Work In Progress",
+		Footers: []string{
+			fmt.Sprintf("Patch-set: %d", patchset),
+			"Work-in-progress: true",
+			"Tag: autogenerated:gerrit:setWorkInProgress",
+		},
+	}
+}
+
+// GitHubUser is a GitHub account.
+type GitHubUser struct {
+	ID    int64
+	Login string
+}
+
+func (u *GitHubUser) proto() *maintpb.GithubUser {
+	if u == nil {
+		return nil
+	}
+	return &maintpb.GithubUser{Id: u.ID, Login: u.Login}
+}
+
+// Issue describes a GitHub issue or pull request.
+type Issue struct {
+	// Owner and Repo default to "golang" and "go".
+	Owner, Repo string
+	Number      int32
+	Title       string
+	Body        string
+	User        *GitHubUser
+	Created     time.Time
+	ClosedAt    time.Time
+	ClosedBy    *GitHubUser
+	Labels      []string
+	Milestone   string
+	Assignees   []*GitHubUser
+	PullRequest bool
+	Comments    []*Comment
+	Events      []*Event
+}
+
+// Comment is a comment on a GitHub issue.
+type Comment struct {
+	User    *GitHubUser
+	Created time.Time
+	Body    string
+}
+
+// Event is a GitHub issue event, such as "closed" or "labeled".
+type Event struct {
+	Type      string
+	Actor     *GitHubUser
+	Created   time.Time
+	Label     string
+	Milestone string
+	Assignee  *GitHubUser
+	CommitID  string
+}
+
+// Builder accumulates fixtures and produces a corpus from them.
+type Builder struct {
+	cls    []*CL
+	issues []*Issue
+
+	ids map[string]int64 // label and milestone names to IDs
+	id  int64            // last allocated ID or hash
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{ids: make(map[string]int64)}
+}
+
+// AddCL adds Gerrit CLs to the corpus.
+func (b *Builder) AddCL(cls ...*CL) {
+	b.cls = append(b.cls, cls...)
+}
+
+// AddIssue adds GitHub issues or pull requests to the corpus.
+func (b *Builder) AddIssue(issues ...*Issue) {
+	b.issues = append(b.issues, issues...)
+}
+
+// Corpus replays the mutation log for the accumulated fixtures into a new
+// corpus.
+func (b *Builder) Corpus(ctx context.Context) (*maintner.Corpus, error) {
+	mutations, err := b.Mutations()
+	if err != nil {
+		return nil, err
+	}
+	corpus := new(maintner.Corpus)
+	if err := corpus.Initialize(ctx, mutationSource(mutations)); err != nil {
+		return nil, err
+	}
+	return corpus, nil
+}
+
+// Mutations returns the mutation log for the accumulated fixtures.
+// GitHub mutations precede Gerrit mutations, so that the issues referenced
+// by CLs exist by the time the CLs are processed.
+func (b *Builder) Mutations() ([]*maintpb.Mutation, error) {
+	var mutations []*maintpb.Mutation
+	for _, issue := range b.issues {
+		m, err := b.issueMutation(issue)
+		if err != nil {
+			return nil, err
+		}
+		mutations = append(mutations, &maintpb.Mutation{GithubIssue: m})
+	}
+	for _, cl := range b.cls {
+		m, err := b.clMutation(cl)
+		if err != nil {
+			return nil, err
+		}
+		mutations = append(mutations, &maintpb.Mutation{Gerrit: m})
+	}
+	return mutations, nil
+}
+
+func (b *Builder) nextID() int64 {
+	b.id++
+	return b.id
+}
+
+func (b *Builder) nameID(kind, name string) int64 {
+	k := kind + ":" + name
+	if id, ok := b.ids[k]; ok {
+		return id
+	}
+	id := b.nextID()
+	b.ids[k] = id
+	return id
+}
+
+func (b *Builder) nextHash() string {
+	return fmt.Sprintf("%040x", b.nextID())
+}
+
+func (b *Builder) clMutation(cl *CL) (*maintpb.GerritMutation, error) {
+	if len(cl.Patchsets) == 0 {
+		return nil, fmt.Errorf("CL %s/%d has no patch sets", cl.Project, cl.Number)
+	}
+	if len(cl.Metas) == 0 {
+		return nil, fmt.Errorf("CL %s/%d has no meta commits", cl.Project, cl.Number)
+	}
+	branch := cl.Branch
+	if branch == "" {
+		branch = "master"
+	}
+	prefix := fmt.Sprintf("refs/changes/%02d/%d/", cl.Number%100, cl.Number)
+	m := &maintpb.GerritMutation{
+		Project: "go.googlesource.com/" + cl.Project,
+	}
+	for i, ps := range cl.Patchsets {
+		if ps.Author == nil {
+			return nil, fmt.Errorf("CL %s/%d patch set %d has no author", cl.Project, cl.Number, i+1)
+		}
+		hash := b.nextHash()
+		commit := &maintpb.GitCommit{
+			Sha1: hash,
+			Raw:  rawCommit(ps.Author.gitPerson(), "", ps.Time, ps.Msg),
+		}
+		if len(ps.Files) > 0 {
+			commit.DiffTree = &maintpb.GitDiffTree{}
+			for _, f := range ps.Files {
+				commit.DiffTree.File = append(commit.DiffTree.File, &maintpb.GitDiffTreeFile{
+					File:    f.Name,
+					Added:   f.Added,
+					Deleted: f.Deleted,
+				})
+			}
+		}
+		m.Commits = append(m.Commits, commit)
+		m.Refs = append(m.Refs, &maintpb.GitRef{
+			Ref:  fmt.Sprintf("%s%d", prefix, i+1),
+			Sha1: hash,
+		})
+	}
+	var parent string
+	for i, meta := range cl.Metas {
+		if meta.Author == nil {
+			return nil, fmt.Errorf("CL %s/%d meta commit %d has no author", cl.Project, cl.Number, i)
+		}
+		msg := meta.msg()
+		if i == 0 {
+			// The root meta commit records the branch and initial status.
+			msg += fmt.Sprintf("Branch: refs/heads/%s\nStatus: new\n", branch)
+		}
+		hash := b.nextHash()
+		m.Commits = append(m.Commits, &maintpb.GitCommit{
+			Sha1: hash,
+			Raw:  rawCommit(meta.Author.metaPerson(), parent, meta.Time, msg),
+		})
+		parent = hash
+	}
+	m.Refs = append(m.Refs, &maintpb.GitRef{
+		Ref:  prefix + "meta",
+		Sha1: parent,
+	})
+	return m, nil
+}
+
+// rawCommit returns the "git cat-file commit" output for a commit.
+func rawCommit(person, parent string, t time.Time, msg string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tree %s\n", emptyTree)
+	if parent != "" {
+		fmt.Fprintf(&b, "parent %s\n", parent)
+	}
+	fmt.Fprintf(&b, "author %s %d +0000\n", person, t.Unix())
+	fmt.Fprintf(&b, "committer %s %d +0000\n", person, t.Unix())
+	b.WriteString("\n")
+	b.WriteString(msg)
+	return []byte(b.String())
+}
+
+func (b *Builder) issueMutation(issue *Issue) (*maintpb.GithubIssueMutation, error) {
+	owner, repo := issue.Owner, issue.Repo
+	if owner == "" && repo == "" {
+		owner, repo = "golang", "go"
+	}
+	if issue.Number == 0 {
+		return nil, fmt.Errorf("issue in %s/%s has no number", owner, repo)
+	}
+	m := &maintpb.GithubIssueMutation{
+		Owner:       owner,
+		Repo:        repo,
+		Number:      issue.Number,
+		Id:          b.nextID(),
+		User:        issue.User.proto(),
+		Title:       issue.Title,
+		Created:     timestamp(issue.Created),
+		Updated:     timestamp(issue.Created),
+		PullRequest: issue.PullRequest,
+		ClosedBy:    issue.ClosedBy.proto(),
+	}
+	if issue.Body != "" {
+		m.BodyChange = &maintpb.StringChange{Val: issue.Body}
+	}
+	if !issue.ClosedAt.IsZero() {
+		m.Closed = &maintpb.BoolChange{Val: true}
+		m.ClosedAt = timestamp(issue.ClosedAt)
+	}
+	if issue.Milestone != "" {
+		m.MilestoneId = b.nameID("milestone", issue.Milestone)
+		m.MilestoneTitle = issue.Milestone
+	}
+	for _, label := range issue.Labels {
+		m.AddLabel = append(m.AddLabel, &maintpb.GithubLabel{
+			Id:   b.nameID("label", label),
+			Name: label,
+		})
+	}
+	assigned := make(map[int64]bool)
+	for _, u := range issue.Assignees {
+		m.Assignees = append(m.Assignees, u.proto())
+		assigned[u.ID] = true
+	}
+	for _, c := range issue.Comments {
+		m.Comment = append(m.Comment, &maintpb.GithubIssueCommentMutation{
+			Id:      b.nextID(),
+			User:    c.User.proto(),
+			Body:    c.Body,
+			Created: timestamp(c.Created),
+			Updated: timestamp(c.Created),
+		})
+	}
+	for _, e := range issue.Events {
+		if e.Actor == nil {
+			return nil, fmt.Errorf("%q event on %s/%s#%d has no actor", e.Type, owner, repo, issue.Number)
+		}
+		event := &maintpb.GithubIssueEvent{
+			Id:        b.nextID(),
+			EventType: e.Type,
+			ActorId:   e.Actor.ID,
+			Created:   timestamp(e.Created),
+		}
+		if e.Label != "" {
+			event.Label = &maintpb.GithubLabel{Name: e.Label}
+		}
+		if e.Milestone != "" {
+			event.Milestone = &maintpb.GithubMilestone{Title: e.Milestone}
+		}
+		if e.Assignee != nil {
+			event.AssigneeId = e.Assignee.ID
+		}
+		if e.CommitID != "" {
+			event.Commit = &maintpb.GithubCommit{Owner: owner, Repo: repo, CommitId: e.CommitID}
+		}
+		m.Event = append(m.Event, event)
+
+		// Events only refer to users by ID. Register each user's login by
+		// adding them as an assignee and immediately removing them again.
+		for _, u := range []*GitHubUser{e.Actor, e.Assignee} {
+			if u == nil || assigned[u.ID] {
+				continue
+			}
+			m.Assignees = append(m.Assignees, u.proto())
+			m.DeletedAssignees = append(m.DeletedAssignees, u.ID)
+			assigned[u.ID] = true
+		}
+	}
+	return m, nil
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// mutationSource replays a fixed mutation log.
+type mutationSource []*maintpb.Mutation
+
+func (s mutationSource) GetMutations(ctx context.Context) <-chan maintner.MutationStreamEvent {
+	ch := make(chan maintner.MutationStreamEvent)
+	go func() {
+		for _, m := range s {
+			select {
+			case ch <- maintner.MutationStreamEvent{Mutation: m}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case ch <- maintner.MutationStreamEvent{End: true}:
+		case <-ctx.Done():
+		}
+	}()
+	return ch
+}
@@ -3,9 +3,12 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/stamblerre/work-stats/generic"
@@ -15,13 +18,29 @@ import (
 )
 
 var (
-	username = flag.String("username", "", "GitHub username")
-	email    = flag.String("email", "", "Gerrit email or emails, comma-separated")
-	weekOf   = flag.String("week", "", "an optional date in the week for which to get snippets (format: 2006-01-02)")
+	username  = flag.String("username", "", "GitHub username")
+	email     = flag.String("email", "", "Gerrit email or emails, comma-separated")
+	accountID = flag.String("gerrit-account-id", "", "Gerrit account ID or IDs, comma-separated, to use instead of looking them up by -email")
+	weekOf    = flag.String("week", "", "an optional date or period in the week for which to get snippets, such as 2006-01-02, last-week, or 2006-W01")
+	tz        = flag.String("tz", "Local", "time zone in which to interpret dates and periods, such as America/New_York")
+
+	// Flags relating to the reporting cadence.
+	cadence     = flag.String("cadence", "weekly", "how often snippets are written: weekly, biweekly, or monthly")
+	weekStart   = flag.String("week-start", "Monday", "the first day of each week")
+	cutoff      = flag.String("cutoff", "Wednesday", "the last day of the week on which snippets are still for the previous period")
+	sprintStart = flag.String("sprint-start", "", "with -cadence=biweekly, the first day of any sprint")
 
 	// Optional flags.
-	gerritFlag = flag.Bool("gerrit", true, "collect data on Go issues or changelists")
-	gitHubFlag = flag.Bool("github", true, "collect data on GitHub issues")
+	gerritFlag     = flag.Bool("gerrit", true, "collect data on Go issues or changelists")
+	gitHubFlag     = flag.Bool("github", true, "collect data on GitHub issues")
+	format         = flag.String("format", "markdown", "output format: "+strings.Join(generic.SnippetFormats(), ", "))
+	issueCounts    = flag.Bool("issue-counts", false, "only report the number of issues worked on, rather than listing them")
+	templateFile   = flag.String("template", "", "path to a text/template file used to render the snippets, instead of a built-in format")
+	group          = flag.Bool("group", false, "group changelists by repository and category")
+	componentsFile = flag.String("components", "", "CODEOWNERS-style file mapping paths to components, by which changelists are categorized (see generic.ComponentMap)")
+	multiCategory  = flag.Bool("multi-category", false, "with -components, report changelists that span several components under each of them")
+	rulesFile      = flag.String("rules", "", "JSON file of rules that classify issues and changelists into categories, such as bug or docs (see generic.LoadRules)")
+	githubCache    = flag.String("github-cache", github.DefaultActivityCachePath(), "file in which to cache comments, reactions, and mentions on GitHub issues and PRs between runs, or \"\" to disable caching")
 )
 
 func main() {
@@ -39,99 +58,161 @@ func main() {
 	}
 	emails := strings.Split(*email, ",")
 
-	start, end, err := generic.InferTimeRange(time.Now(), *weekOf)
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := parseCadence(time.Now().In(loc))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var rules generic.Rules
+	if *rulesFile != "" {
+		if rules, err = generic.LoadRules(*rulesFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var components *generic.ComponentMap
+	if *componentsFile != "" {
+		if components, err = generic.LoadComponentMap(*componentsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	r, err := c.InferTimeRange(time.Now().In(loc), *weekOf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Generating %s snippets for dates %s", c.Interval, r)
+
+	tmpl, err := parseTemplate(*format, *templateFile)
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Printf("Generating weekly snippets for dates %s to %s", start.Format("01-02-2006"), end.Format("01-02-2006"))
 
-	var b strings.Builder
+	snippets := &generic.Snippets{
+		Start:            r.Start,
+		End:              r.End,
+		CollapseIssues:   *issueCounts,
+		GroupChangelists: *group,
+	}
+	var gerritAuthored, gerritReviewed []*generic.Changelist
 	if *gerritFlag {
 		corpus, err := godata.Get(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
-		authored, reviewed, err := golang.Changelists(corpus.Gerrit(), emails, start, end)
+		ids, err := golang.ParseAccountIDs(*accountID)
 		if err != nil {
 			log.Fatal(err)
 		}
-		issues, err := golang.Issues(corpus.GitHub(), "", *username, start, end)
+		accounts, err := golang.ResolveAccounts(corpus.Gerrit(), emails, ids)
 		if err != nil {
 			log.Fatal(err)
 		}
-		var merged, inProgress []*generic.Changelist
-		for _, cl := range authored {
-			if generic.IsMergedBefore(cl, end) {
-				merged = append(merged, cl)
-			} else {
-				inProgress = append(inProgress, cl)
-			}
+		for _, d := range accounts.Diagnostics {
+			log.Print(d)
 		}
-		if len(merged) > 0 {
-			b.WriteString("## CLs Merged\n\n")
-			for _, cl := range merged {
-				b.WriteString(formatCL(cl))
-			}
+		authored, reviewed, err := golang.Changelists(corpus.Gerrit(), accounts, r)
+		if err != nil {
+			log.Fatal(err)
 		}
-		if len(inProgress) > 0 {
-			b.WriteString("\n## CLs In Progress\n\n")
-			for _, cl := range inProgress {
-				b.WriteString(formatCL(cl))
-			}
+		fixes, err := golang.IndexFixes(corpus)
+		if err != nil {
+			log.Fatal(err)
 		}
-		if len(reviewed) > 0 {
-			b.WriteString("\n## CLs Reviewed\n\n")
-			for _, cl := range reviewed {
-				b.WriteString(formatCL(cl))
-			}
+		issues, err := golang.Issues(corpus, fixes, "", *username, accounts, r)
+		if err != nil {
+			log.Fatal(err)
 		}
-		if len(issues) > 0 {
-			b.WriteString(fmt.Sprintf("\n### Commented on %v golang/go issues\n\n", len(issues)))
+		// Backports to release branches are work the user sent, too.
+		backports, err := golang.Backports(corpus.Gerrit(), accounts, r)
+		if err != nil {
+			log.Fatal(err)
 		}
+		authored = append(authored, backports...)
+		components.Classify(authored, *multiCategory)
+		components.Classify(reviewed, *multiCategory)
+		rules.ClassifyChangelists(authored)
+		rules.ClassifyChangelists(reviewed)
+		rules.ClassifyIssues(issues)
+		gerritAuthored, gerritReviewed = authored, reviewed
+		snippets.Sources = append(snippets.Sources, generic.NewSnippetSource("golang/go", "CL", *username, r, authored, reviewed, issues))
 	}
 
 	if *gitHubFlag {
-		authored, reviewed, issues, err := github.IssuesAndPRs(ctx, *username, start, end)
+		client, err := github.NewClient(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
-		var merged, inProgress []*generic.Changelist
-		for _, pr := range authored {
-			if generic.IsMergedBefore(pr, end) {
-				merged = append(merged, pr)
-			} else {
-				inProgress = append(inProgress, pr)
-			}
-		}
-		if len(merged) > 0 {
-			b.WriteString("## PRs Merged\n\n")
-			for _, pr := range merged {
-				b.WriteString(formatPR(pr))
+		var cache *github.ActivityCache
+		if *githubCache != "" {
+			cache, err = github.OpenActivityCache(*githubCache)
+			if err != nil {
+				log.Fatal(err)
 			}
 		}
-		if len(inProgress) > 0 {
-			b.WriteString("\n## PRs In Progress\n\n")
-			for _, pr := range inProgress {
-				b.WriteString(formatPR(pr))
-			}
+		authored, reviewed, issues, err := github.IssuesAndPRs(ctx, client, cache, *username, r)
+		if err != nil {
+			log.Fatal(err)
 		}
-		if len(reviewed) > 0 {
-			b.WriteString("\n## PRs Reviewed\n\n")
-			for _, pr := range reviewed {
-				b.WriteString(formatPR(pr))
-			}
+		if err := cache.Save(); err != nil {
+			log.Fatal(err)
 		}
-		if len(issues) > 0 {
-			b.WriteString(fmt.Sprintf("\n### Commented on %v GitHub issues\n\n", len(issues)))
+		// Report changes mirrored between Gerrit and GitHub only once.
+		authored = generic.DedupChangelists(gerritAuthored, authored)
+		reviewed = generic.DedupChangelists(gerritReviewed, reviewed)
+		components.Classify(authored, *multiCategory)
+		components.Classify(reviewed, *multiCategory)
+		rules.ClassifyChangelists(authored)
+		rules.ClassifyChangelists(reviewed)
+		rules.ClassifyIssues(issues)
+		discussions, err := github.Discussions(ctx, client, *username, r)
+		if err != nil {
+			log.Fatal(err)
 		}
+		src := generic.NewSnippetSource("GitHub", "PR", *username, r, authored, reviewed, issues)
+		src.Discussions = discussions
+		snippets.Sources = append(snippets.Sources, src)
+	}
+	if err := generic.WriteSnippets(os.Stdout, tmpl, snippets); err != nil {
+		log.Fatal(err)
 	}
-	fmt.Println(b.String())
 }
 
-func formatCL(cl *generic.Changelist) string {
-	return fmt.Sprintf(" * [%d](https://%s): %s\n", cl.Number, cl.Link, cl.Subject)
+// parseTemplate parses the template in the given file, or the built-in
+// template for format if filename is empty.
+func parseTemplate(format, filename string) (*template.Template, error) {
+	if filename == "" {
+		return generic.SnippetFormat(format)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return generic.ParseSnippetTemplate(filepath.Base(filename), string(data))
 }
 
-func formatPR(pr *generic.Changelist) string {
-	return fmt.Sprintf("* [%s#%d](%s): %s\n", pr.Repo, pr.Number, pr.Link, pr.Subject)
+// parseCadence returns the cadence described by the -cadence, -week-start,
+// -cutoff, and -sprint-start flags.
+func parseCadence(now time.Time) (generic.Cadence, error) {
+	var c generic.Cadence
+	var err error
+	if c.Interval, err = generic.ParseInterval(*cadence); err != nil {
+		return c, err
+	}
+	if c.WeekStart, err = generic.ParseWeekday(*weekStart); err != nil {
+		return c, err
+	}
+	if c.Cutoff, err = generic.ParseWeekday(*cutoff); err != nil {
+		return c, err
+	}
+	if *sprintStart != "" {
+		sprint, err := generic.ParsePeriod(now, *sprintStart)
+		if err != nil {
+			return c, err
+		}
+		c.Anchor = sprint.Start
+	}
+	return c, nil
 }